@@ -0,0 +1,539 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	install "github.com/AmitKumarDas/decide/pkg/install/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// ExitError is returned by a command that has run to completion but whose
+// outcome needs the process to exit with the given code
+type ExitError struct {
+	Code int
+}
+
+// Error is an implementation of error interface
+func (e ExitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.Code)
+}
+
+// ExitCode returns the process exit code corresponding to the error returned
+// by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exitErr, ok := err.(ExitError); ok {
+		return exitErr.Code
+	}
+	return 1
+}
+
+// exitWith returns the error corresponding to the given exit code; nil if the
+// code implies success
+func exitWith(code int) error {
+	if code == 0 {
+		return nil
+	}
+	return ExitError{Code: code}
+}
+
+// NewCommand returns the root command of decide
+//
+// NOTE:
+//  The flags that map onto the environment keys are applied before any of
+// the sub commands is run
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "decide",
+		Short:         "decide installs openebs artifacts into kubernetes cluster",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return applyEnvFlags(cmd.Flags(), os.Setenv)
+		},
+	}
+
+	cmd.AddCommand(
+		newInstallCommand(),
+		newUninstallCommand(),
+		newPlanCommand(),
+		newRenderCommand(),
+		newDriftCommand(),
+		newPruneCommand(),
+		newUpgradeCommand(),
+		newVersionsCommand(),
+		newArtifactsCommand(),
+		newControllerCommand(),
+	)
+	return cmd
+}
+
+// newInstallCommand returns the command that installs the artifacts
+// specified in the install config
+//
+// NOTE:
+//  Install run is recorded against the install config only if the install
+// config is fetched from its config map
+//
+// NOTE:
+//  Install is interrupted on SIGINT or SIGTERM; the resources that were never
+// applied are reported as not attempted
+func newInstallCommand() *cobra.Command {
+	o := &options{}
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the artifacts specified in the install config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			installer := install.SimpleInstaller()
+			if o.isConfigFile() {
+				installer = install.NewContextSimpleInstaller(o.configGetter())
+			}
+
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			report := installer.InstallContext(ctx)
+			if format == OutputFormatTable {
+				fmt.Fprint(cmd.OutOrStdout(), report.Table())
+			} else if err := printObject(cmd.OutOrStdout(), report, format); err != nil {
+				return err
+			}
+
+			return exitWith(report.ExitCode())
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// newUninstallCommand returns the command that deletes the artifacts
+// specified in the uninstall section of the install config
+//
+// NOTE:
+//  Uninstall is interrupted on SIGINT or SIGTERM
+func newUninstallCommand() *cobra.Command {
+	o := &options{}
+
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Uninstall the artifacts specified in the install config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			errs := install.NewContextSimpleUninstaller(o.configGetter()).Uninstall(ctx)
+			if len(errs) != 0 {
+				printErrors(cmd.OutOrStderr(), errs)
+				return exitWith(1)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "uninstalled")
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	return cmd
+}
+
+// newPlanCommand returns the command that shows the changes install would
+// make without making them
+//
+// NOTE:
+//  Planning is interrupted on SIGINT or SIGTERM
+func newPlanCommand() *cobra.Command {
+	o := &options{}
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show the changes install would make to the cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			plan, errs := install.NewContextSimpleInstaller(o.configGetter()).Plan(ctx)
+			if format == OutputFormatTable {
+				fmt.Fprint(cmd.OutOrStdout(), plan.String())
+			} else if err := printObject(cmd.OutOrStdout(), plan, format); err != nil {
+				return err
+			}
+
+			if len(errs) != 0 {
+				printErrors(cmd.OutOrStderr(), errs)
+				return exitWith(1)
+			}
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// newRenderCommand returns the command that prints the resources install
+// would apply
+func newRenderCommand() *cobra.Command {
+	o := &options{}
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Print the resources specified in the install config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatYAML, OutputFormatJSON)
+			if err != nil {
+				return err
+			}
+
+			rendered, errs := install.NewContextSimpleInstaller(o.configGetter()).Render(install.RenderFormat(format))
+			if len(errs) != 0 {
+				printErrors(cmd.OutOrStderr(), errs)
+				return exitWith(1)
+			}
+
+			_, err = cmd.OutOrStdout().Write(rendered)
+			return err
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatYAML), "output format: yaml or json")
+	return cmd
+}
+
+// newDriftCommand returns the command that compares the resources specified
+// in the install config against the live resources
+//
+// NOTE:
+//  The command exits with 2 if any of the resources has drifted & was not
+// healed; 1 if the drift could not be detected
+//
+// NOTE:
+//  Drift detection is interrupted on SIGINT or SIGTERM
+func newDriftCommand() *cobra.Command {
+	o := &options{}
+	var output, mode string
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report or heal the resources that differ from the install config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			driftMode, err := install.DriftModeFor(mode)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			report, errs := install.NewContextSimpleInstaller(o.configGetter()).DetectDrift(ctx, driftMode)
+			if format == OutputFormatTable {
+				fmt.Fprint(cmd.OutOrStdout(), report.String())
+			} else if err := printObject(cmd.OutOrStdout(), report, format); err != nil {
+				return err
+			}
+
+			if len(errs) != 0 {
+				printErrors(cmd.OutOrStderr(), errs)
+				return exitWith(1)
+			}
+			return exitWith(report.ExitCode())
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVar(&mode, "mode", string(install.DriftModeReport), "what to do with the drifted resources: report or heal")
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// newPruneCommand returns the command that deletes the resources owned by
+// the install config that are no longer specified in it
+//
+// NOTE:
+//  The resources are owned by the install config name which is needed even
+// if the install config is read from a file
+//
+// NOTE:
+//  Pruning is interrupted on SIGINT or SIGTERM
+func newPruneCommand() *cobra.Command {
+	o := &options{}
+	var output string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the owned resources that are no longer in the install config",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			items, errs := install.NewContextSimpleInstaller(o.configGetter()).Prune(ctx, dryRun)
+			if format != OutputFormatTable {
+				err = printObject(cmd.OutOrStdout(), items, format)
+			} else {
+				err = printPruneItems(cmd.OutOrStdout(), items)
+			}
+			if err != nil {
+				return err
+			}
+
+			failed := false
+			for _, item := range items {
+				failed = failed || item.Action == install.PruneActionFailed
+			}
+			if len(errs) != 0 || failed {
+				printErrors(cmd.OutOrStderr(), errs)
+				return exitWith(1)
+			}
+			return nil
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only list the resources that would be deleted")
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// printPruneItems prints the given prune report items one per line
+func printPruneItems(w io.Writer, items []install.PruneReportItem) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tAPIVERSION\tKIND\tNAMESPACE\tNAME\tVERSION")
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Action, item.APIVersion, item.Kind, item.Namespace, item.Name, item.Version)
+	}
+	for _, item := range items {
+		if len(item.Error) != 0 {
+			fmt.Fprintf(tw, "error: %s\n", item.Error)
+		}
+	}
+	return tw.Flush()
+}
+
+// newUpgradeCommand returns the command that moves the installed artifacts
+// from one version to another
+//
+// NOTE:
+//  The install config is not updated; it should be updated to the new version
+// to avoid a later install from reverting this upgrade
+//
+// NOTE:
+//  Upgrade is interrupted on SIGINT or SIGTERM
+func newUpgradeCommand() *cobra.Command {
+	o := &options{}
+	var output, from, to string
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade the installed artifacts from one version to another",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+			if len(from) == 0 || len(to) == 0 {
+				return fmt.Errorf("missing version: both '--from' and '--to' are required")
+			}
+			if from == to {
+				return fmt.Errorf("invalid versions: '--from' and '--to' are both '%s'", from)
+			}
+			if !isSupportedVersion(to) {
+				return fmt.Errorf("unsupported version '%s': supported versions are '%s'", to, strings.Join(install.ListVersions(), "', '"))
+			}
+
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			report := install.NewContextSimpleInstaller(o.configGetter()).Upgrade(ctx, from, to)
+			if format == OutputFormatTable {
+				fmt.Fprint(cmd.OutOrStdout(), report.String())
+			} else if err := printObject(cmd.OutOrStdout(), report, format); err != nil {
+				return err
+			}
+
+			return exitWith(report.ExitCode())
+		},
+	}
+
+	o.addFlags(cmd.Flags())
+	cmd.Flags().StringVar(&from, "from", "", "installed version to upgrade from")
+	cmd.Flags().StringVar(&to, "to", "", "version to upgrade to")
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// isSupportedVersion returns true if the given version can be installed
+func isSupportedVersion(version string) bool {
+	for _, supported := range install.ListVersions() {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// newVersionsCommand returns the command that lists the versions that can be
+// installed
+func newVersionsCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "versions",
+		Short: "List the versions that can be installed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			versions := install.ListVersions()
+			if format != OutputFormatTable {
+				return printObject(cmd.OutOrStdout(), versions, format)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), strings.Join(versions, "\n"))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// newArtifactsCommand returns the command that lists the artifacts of a
+// version
+func newArtifactsCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "artifacts VERSION",
+		Short: "List the artifacts of the given version",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := outputFormatFor(output, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if err != nil {
+				return err
+			}
+
+			list, err := install.ListArtifactsByVersion(args[0])
+			if err != nil {
+				return err
+			}
+
+			unstructs, errs := install.TransformArtifactToUnstructuredList(list)
+			if len(errs) != 0 {
+				printErrors(cmd.OutOrStderr(), errs)
+				return exitWith(1)
+			}
+
+			if format != OutputFormatTable {
+				render, err := install.UnstructuredListRendererFor(install.RenderFormat(format))
+				if err != nil {
+					return err
+				}
+
+				rendered, err := render(unstructs)
+				if err != nil {
+					return errors.Wrapf(err, "failed to list artifacts of version '%s'", args[0])
+				}
+
+				_, err = cmd.OutOrStdout().Write(rendered)
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "APIVERSION\tKIND\tNAMESPACE\tNAME")
+			for _, unstruct := range unstructs {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", unstruct.GetAPIVersion(), unstruct.GetKind(), unstruct.GetNamespace(), unstruct.GetName())
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", string(OutputFormatTable), "output format: table, json or yaml")
+	return cmd
+}
+
+// newControllerCommand returns the command that runs install whenever the
+// install config changes as well as at regular intervals
+//
+// NOTE:
+//  The controller stops on SIGINT or SIGTERM after interrupting the install
+// that is in progress
+func newControllerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Run install whenever the install config map changes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			return install.InstallController().Run(ctx.Done())
+		},
+	}
+
+	addEnvFlags(cmd.Flags())
+	return cmd
+}
@@ -0,0 +1,466 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	install "github.com/AmitKumarDas/decide/pkg/install/v1alpha1"
+	"github.com/spf13/pflag"
+)
+
+func TestApplyEnvFlags(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		expected map[string]string
+	}{
+		"no flags leave env untouched": {
+			args:     []string{},
+			expected: map[string]string{},
+		},
+		"flag with value": {
+			args:     []string{"--config-name", "openebs-install", "--concurrency=4"},
+			expected: map[string]string{string(install.EnvKeyForInstallConfigName): "openebs-install", string(install.EnvKeyForInstallConcurrency): "4"},
+		},
+		"bool flag without value": {
+			args:     []string{"--prune"},
+			expected: map[string]string{string(install.EnvKeyForInstallPrune): "true"},
+		},
+		"bool flag with value": {
+			args:     []string{"--transactional=false"},
+			expected: map[string]string{string(install.EnvKeyForInstallTransactional): "false"},
+		},
+		"timeout flags": {
+			args:     []string{"--timeout", "10m", "--object-timeout=30s"},
+			expected: map[string]string{string(install.EnvKeyForInstallTimeout): "10m", string(install.EnvKeyForInstallObjectTimeout): "30s"},
+		},
+		"controller flags": {
+			args: []string{"--resync-interval=5m", "--metrics-address", ":9500", "--leader-election", "--leader-identity", "replica-a"},
+			expected: map[string]string{
+				string(install.EnvKeyForInstallResyncInterval): "5m",
+				string(install.EnvKeyForInstallMetricsAddress): ":9500",
+				string(install.EnvKeyForInstallLeaderElection): "true",
+				string(install.EnvKeyForInstallLeaderIdentity): "replica-a",
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			addEnvFlags(flags)
+			if err := flags.Parse(mock.args); err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+
+			got := map[string]string{}
+			err := applyEnvFlags(flags, func(key, value string) error {
+				got[key] = value
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+
+			if len(got) != len(mock.expected) {
+				t.Fatalf("expected env '%v': got '%v'", mock.expected, got)
+			}
+			for key, value := range mock.expected {
+				if got[key] != value {
+					t.Fatalf("expected env '%s' to be '%s': got '%s'", key, value, got[key])
+				}
+			}
+		})
+	}
+}
+
+// installEnvKeys returns the names of the environment keys declared in the
+// given file of the install library
+func installEnvKeys(t *testing.T, path string) (names []string) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			for _, name := range spec.(*ast.ValueSpec).Names {
+				if strings.HasPrefix(name.Name, "EnvKeyFor") {
+					names = append(names, name.Name)
+				}
+			}
+		}
+	}
+	return
+}
+
+func TestEnvFlagsCoverInstallEnvKeys(t *testing.T) {
+	names := installEnvKeys(t, filepath.Join("..", "..", "..", "pkg", "install", "v1alpha1", "env.go"))
+	if len(names) == 0 {
+		t.Fatalf("expected install environment keys: got none")
+	}
+
+	// the values of the keys are compared since a key may be declared as a
+	// conversion of another key
+	values := map[string]bool{}
+	for _, f := range envFlags {
+		values[f.envKey] = true
+	}
+
+	// every key declared in env.go is expected to be listed here
+	keys := map[string]install.InstallENVKey{
+		"EnvKeyForInstallConfigName":       install.EnvKeyForInstallConfigName,
+		"EnvKeyForInstallConfigNamespace":  install.EnvKeyForInstallConfigNamespace,
+		"EnvKeyForInstallConcurrency":      install.EnvKeyForInstallConcurrency,
+		"EnvKeyForInstallTransactional":    install.EnvKeyForInstallTransactional,
+		"EnvKeyForInstallWaitForReadiness": install.EnvKeyForInstallWaitForReadiness,
+		"EnvKeyForInstallReadinessTimeout": install.EnvKeyForInstallReadinessTimeout,
+		"EnvKeyForInstallResyncInterval":   install.EnvKeyForInstallResyncInterval,
+		"EnvKeyForInstallPrune":            install.EnvKeyForInstallPrune,
+		"EnvKeyForInstallMetricsAddress":   install.EnvKeyForInstallMetricsAddress,
+		"EnvKeyForInstallLeaderElection":   install.EnvKeyForInstallLeaderElection,
+		"EnvKeyForInstallLeaderIdentity":   install.EnvKeyForInstallLeaderIdentity,
+		"EnvKeyForInstallTimeout":          install.EnvKeyForInstallTimeout,
+		"EnvKeyForInstallObjectTimeout":    install.EnvKeyForInstallObjectTimeout,
+		"EnvKeyForInstallDriftMode":        install.EnvKeyForInstallDriftMode,
+	}
+
+	for _, name := range names {
+		key, found := keys[name]
+		if !found {
+			t.Fatalf("expected env key '%s' to be listed in this test", name)
+		}
+		if !values[string(key)] {
+			t.Fatalf("expected a flag for env key '%s' (%s)", name, key)
+		}
+	}
+}
+
+func TestOutputFormatFor(t *testing.T) {
+	tests := map[string]struct {
+		value     string
+		expected  OutputFormat
+		expectErr bool
+	}{
+		"table":            {value: "table", expected: OutputFormatTable},
+		"upper case json":  {value: "JSON", expected: OutputFormatJSON},
+		"yaml":             {value: "yaml", expected: OutputFormatYAML},
+		"unsupported wide": {value: "wide", expectErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := outputFormatFor(mock.value, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if got != mock.expected {
+				t.Fatalf("expected format '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected int
+	}{
+		"no error":      {expected: 0},
+		"exit error":    {err: ExitError{Code: 3}, expected: 3},
+		"generic error": {err: fmt.Errorf("boom"), expected: 1},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ExitCode(mock.err); got != mock.expected {
+				t.Fatalf("expected exit code '%d': got '%d'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestRenderCommandWithConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "decide")
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(path, []byte("spec:\n  install:\n  - version: 0.7.0\n    set:\n      namespace: openebs\n"), 0644)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	var out bytes.Buffer
+	cmd := NewCommand()
+	cmd.SetOutput(&out)
+	cmd.SetArgs([]string{"render", "--config-file", path, "-o", "yaml"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("expected no error: got '%v': %s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), "kind: CASTemplate") {
+		t.Fatalf("expected rendered cas templates: got '%s'", out.String())
+	}
+}
+
+func TestVersionsCommand(t *testing.T) {
+	var out bytes.Buffer
+	cmd := NewCommand()
+	cmd.SetOutput(&out)
+	cmd.SetArgs([]string{"versions", "-o", "json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	if !strings.Contains(out.String(), `"0.7.0"`) {
+		t.Fatalf("expected version '0.7.0': got '%s'", out.String())
+	}
+}
+
+// writeConfigFile writes an install config of the given version to a
+// temporary file & returns its path along with the cleanup function
+func writeConfigFile(t *testing.T, version string) (string, func()) {
+	dir, err := ioutil.TempDir("", "decide")
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	path := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(path, []byte("spec:\n  install:\n  - version: "+version+"\n    set:\n      namespace: openebs\n"), 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestDriftCommand(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "0.7.0")
+	defer cleanup()
+
+	tests := map[string]struct {
+		args           []string
+		expectExitCode int
+		expectOutput   string
+	}{
+		"unsupported mode": {
+			args:           []string{"drift", "--config-file", path, "--mode", "fix"},
+			expectExitCode: 1,
+			expectOutput:   "unsupported drift mode 'fix'",
+		},
+		"unsupported output": {
+			args:           []string{"drift", "--config-file", path, "-o", "wide"},
+			expectExitCode: 1,
+			expectOutput:   "unsupported output format 'wide'",
+		},
+		// live resources can not be fetched in the absence of a kubernetes
+		// cluster
+		"drift not detected": {
+			args:           []string{"drift", "--config-file", path, "--mode", "report", "-o", "json"},
+			expectExitCode: 1,
+			expectOutput:   `"mode": "report"`,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewCommand()
+			cmd.SetOutput(&out)
+			cmd.SetArgs(mock.args)
+
+			err := cmd.Execute()
+			if got := ExitCode(err); got != mock.expectExitCode {
+				t.Fatalf("expected exit code '%d': got '%d': %s", mock.expectExitCode, got, out.String())
+			}
+
+			output := out.String()
+			if err != nil {
+				output += err.Error()
+			}
+			if !strings.Contains(output, mock.expectOutput) {
+				t.Fatalf("expected output to contain '%s': got '%s'", mock.expectOutput, output)
+			}
+		})
+	}
+}
+
+func TestPruneCommand(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "0.7.0")
+	defer cleanup()
+	os.Unsetenv(string(install.EnvKeyForInstallConfigName))
+
+	tests := map[string]struct {
+		args           []string
+		expectExitCode int
+		expectOutput   string
+	}{
+		"unsupported output": {
+			args:           []string{"prune", "--config-file", path, "--dry-run", "-o", "wide"},
+			expectExitCode: 1,
+			expectOutput:   "unsupported output format 'wide'",
+		},
+		"dry run without install config name": {
+			args:           []string{"prune", "--config-file", path, "--dry-run"},
+			expectExitCode: 1,
+			expectOutput:   "missing install config name",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewCommand()
+			cmd.SetOutput(&out)
+			cmd.SetArgs(mock.args)
+
+			err := cmd.Execute()
+			if got := ExitCode(err); got != mock.expectExitCode {
+				t.Fatalf("expected exit code '%d': got '%d': %s", mock.expectExitCode, got, out.String())
+			}
+
+			output := out.String()
+			if err != nil {
+				output += err.Error()
+			}
+			if !strings.Contains(output, mock.expectOutput) {
+				t.Fatalf("expected output to contain '%s': got '%s'", mock.expectOutput, output)
+			}
+		})
+	}
+}
+
+func TestPrintPruneItems(t *testing.T) {
+	var out bytes.Buffer
+	err := printPruneItems(&out, []install.PruneReportItem{
+		{APIVersion: "openebs.io/v1alpha1", Kind: "CASTemplate", Name: "jiva-volume-read-default-0.6.0", Version: "0.6.0", Action: install.PruneActionWouldDelete},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "openebs", Name: "stale", Action: install.PruneActionFailed, Error: "forbidden"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	for _, expected := range []string{"ACTION", "would-delete", "jiva-volume-read-default-0.6.0", "error: forbidden"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected output to contain '%s': got '%s'", expected, out.String())
+		}
+	}
+}
+
+func TestUpgradeCommand(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "0.7.0")
+	defer cleanup()
+
+	tests := map[string]struct {
+		args           []string
+		expectExitCode int
+		expectOutput   string
+	}{
+		"missing to version": {
+			args:           []string{"upgrade", "--config-file", path, "--from", "0.6.0"},
+			expectExitCode: 1,
+			expectOutput:   "both '--from' and '--to' are required",
+		},
+		"same versions": {
+			args:           []string{"upgrade", "--config-file", path, "--from", "0.7.0", "--to", "0.7.0"},
+			expectExitCode: 1,
+			expectOutput:   "are both '0.7.0'",
+		},
+		"unsupported to version": {
+			args:           []string{"upgrade", "--config-file", path, "--from", "0.7.0", "--to", "9.9.9"},
+			expectExitCode: 1,
+			expectOutput:   "unsupported version '9.9.9'",
+		},
+		"from version not in install config": {
+			args:           []string{"upgrade", "--config-file", path, "--from", "0.6.0", "--to", "0.7.0", "-o", "json"},
+			expectExitCode: 1,
+			expectOutput:   "version '0.6.0' is not specified in install config",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewCommand()
+			cmd.SetOutput(&out)
+			cmd.SetArgs(mock.args)
+
+			err := cmd.Execute()
+			if got := ExitCode(err); got != mock.expectExitCode {
+				t.Fatalf("expected exit code '%d': got '%d': %s", mock.expectExitCode, got, out.String())
+			}
+
+			output := out.String()
+			if err != nil {
+				output += err.Error()
+			}
+			if !strings.Contains(output, mock.expectOutput) {
+				t.Fatalf("expected output to contain '%s': got '%s'", mock.expectOutput, output)
+			}
+		})
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	tests := map[string]struct {
+		expectFlags []string
+	}{
+		"install":    {expectFlags: []string{"output"}},
+		"uninstall":  {},
+		"plan":       {expectFlags: []string{"output"}},
+		"render":     {expectFlags: []string{"output"}},
+		"drift":      {expectFlags: []string{"mode", "output"}},
+		"prune":      {expectFlags: []string{"dry-run", "output"}},
+		"upgrade":    {expectFlags: []string{"from", "to", "output"}},
+		"versions":   {expectFlags: []string{"output"}},
+		"artifacts":  {expectFlags: []string{"output"}},
+		"controller": {},
+	}
+
+	root := NewCommand()
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd, _, err := root.Find([]string{name})
+			if err != nil || cmd.Name() != name {
+				t.Fatalf("expected sub command '%s': got '%v'", name, err)
+			}
+			for _, flag := range mock.expectFlags {
+				if cmd.Flags().Lookup(flag) == nil {
+					t.Fatalf("expected flag '%s' of sub command '%s': got none", flag, name)
+				}
+			}
+		})
+	}
+}
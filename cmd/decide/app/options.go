@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	install "github.com/AmitKumarDas/decide/pkg/install/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// envFlag is a command line flag that overrides the value of an environment
+// key
+type envFlag struct {
+	name   string
+	envKey string
+	usage  string
+	// isBool flags can be set without a value e.g. --prune
+	isBool bool
+}
+
+// envFlags are the flags that map onto the environment keys understood by
+// the install library
+//
+// NOTE:
+//  A flag takes precedence over its environment key which in turn takes
+// precedence over the library default
+var envFlags = []envFlag{
+	{name: "config-name", envKey: string(install.EnvKeyForInstallConfigName), usage: "name of the config map that has the install config"},
+	{name: "namespace", envKey: string(install.EnvKeyForInstallConfigNamespace), usage: "namespace of the install config"},
+	{name: "kubeconfig", envKey: string(k8s.EnvironmentKeyForKubeConfig), usage: "path to the kubeconfig file"},
+	{name: "master", envKey: string(k8s.EnvironmentKeyForK8sMasterIP), usage: "address of the kubernetes api server"},
+	{name: "concurrency", envKey: string(install.EnvKeyForInstallConcurrency), usage: "number of resources applied concurrently"},
+	{name: "transactional", envKey: string(install.EnvKeyForInstallTransactional), usage: "roll back the applied resources if install fails", isBool: true},
+	{name: "wait-for-readiness", envKey: string(install.EnvKeyForInstallWaitForReadiness), usage: "wait for the applied resources to be ready", isBool: true},
+	{name: "readiness-timeout", envKey: string(install.EnvKeyForInstallReadinessTimeout), usage: "maximum time to wait for a resource to be ready e.g. 5m"},
+	{name: "prune", envKey: string(install.EnvKeyForInstallPrune), usage: "delete the owned resources no longer in the install config", isBool: true},
+	{name: "timeout", envKey: string(install.EnvKeyForInstallTimeout), usage: "maximum time an install run may take e.g. 10m"},
+	{name: "object-timeout", envKey: string(install.EnvKeyForInstallObjectTimeout), usage: "maximum time to apply a single resource e.g. 30s"},
+	{name: "drift-mode", envKey: string(install.EnvKeyForInstallDriftMode), usage: "what the controller does on detecting a drift at every resync: report & re-install or heal"},
+	{name: "resync-interval", envKey: string(install.EnvKeyForInstallResyncInterval), usage: "interval at which the controller checks the install even if the install config has not changed e.g. 10m"},
+	{name: "metrics-address", envKey: string(install.EnvKeyForInstallMetricsAddress), usage: "address to serve the install metrics at e.g. :9500"},
+	{name: "leader-election", envKey: string(install.EnvKeyForInstallLeaderElection), usage: "reconcile only after being elected as the leader amongst the controller replicas", isBool: true},
+	{name: "leader-identity", envKey: string(install.EnvKeyForInstallLeaderIdentity), usage: "identity of this controller replica during leader election; defaults to the host name"},
+}
+
+// addEnvFlags adds the flags that map onto the environment keys to the given
+// flag set
+func addEnvFlags(flags *pflag.FlagSet) {
+	for _, f := range envFlags {
+		flags.String(f.name, "", fmt.Sprintf("%s (env %s)", f.usage, f.envKey))
+		if f.isBool {
+			flags.Lookup(f.name).NoOptDefVal = "true"
+		}
+	}
+}
+
+// EnvSetter abstracts setting an environment key
+type EnvSetter func(key, value string) error
+
+// applyEnvFlags sets the environment keys corresponding to the flags that
+// were set explicitly
+//
+// NOTE:
+//  Environment keys of the flags that were not set are left untouched so that
+// the environment or the library default is used
+func applyEnvFlags(flags *pflag.FlagSet, setenv EnvSetter) error {
+	for _, f := range envFlags {
+		flag := flags.Lookup(f.name)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+
+		err := setenv(f.envKey, strings.TrimSpace(flag.Value.String()))
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply flag '--%s'", f.name)
+		}
+	}
+	return nil
+}
+
+// options are the flags common to the commands that need the install config
+type options struct {
+	// configFile is the path to a local file that has the install config;
+	// the install config is fetched from its config map if not set
+	configFile string
+}
+
+// addFlags adds the common flags to the given flag set
+func (o *options) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.configFile, "config-file", "", "path to the install config file; used instead of the install config map")
+	addEnvFlags(flags)
+}
+
+// configGetter returns the getter to fetch the install config either from the
+// config file or from the install config map
+//
+// NOTE:
+//  The install config map is fetched within the context of the command
+func (o *options) configGetter() install.ContextConfigGetterFunc {
+	if len(strings.TrimSpace(o.configFile)) != 0 {
+		return install.IgnoreContextConfigGetter(install.WithFileConfigGetter(o.configFile))
+	}
+
+	return install.WithContextConfigMapConfigGetter(env.Get(string(install.EnvKeyForInstallConfigNamespace)))
+}
+
+// isConfigFile returns true if the install config is read from a local file
+func (o *options) isConfigFile() bool {
+	return len(strings.TrimSpace(o.configFile)) != 0
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// OutputFormat is a typed string to represent the formats a command can
+// print its outcome in
+type OutputFormat string
+
+const (
+	// OutputFormatTable prints the outcome in a human readable form
+	OutputFormatTable OutputFormat = "table"
+	// OutputFormatJSON prints the outcome as JSON
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatYAML prints the outcome as YAML
+	OutputFormatYAML OutputFormat = "yaml"
+)
+
+// outputFormatFor returns the output format corresponding to the given value
+// if it is one of the supported formats
+func outputFormatFor(value string, supported ...OutputFormat) (OutputFormat, error) {
+	var names []string
+	for _, format := range supported {
+		if OutputFormat(strings.ToLower(strings.TrimSpace(value))) == format {
+			return format, nil
+		}
+		names = append(names, string(format))
+	}
+
+	return "", fmt.Errorf("unsupported output format '%s': supported formats are '%s'", value, strings.Join(names, "', '"))
+}
+
+// printObject prints the given object as JSON or YAML
+func printObject(w io.Writer, obj interface{}, format OutputFormat) error {
+	raw, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to print as %s", format)
+	}
+
+	if format == OutputFormatYAML {
+		raw, err = yaml.JSONToYAML(raw)
+		if err != nil {
+			return errors.Wrapf(err, "failed to print as %s", format)
+		}
+	}
+
+	_, err = fmt.Fprintln(w, strings.TrimSpace(string(raw)))
+	return err
+}
+
+// printErrors prints the given errors one per line
+func printErrors(w io.Writer, errs []error) {
+	for _, err := range errs {
+		fmt.Fprintf(w, "error: %s\n", err)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// shutdownSignals are the signals that interrupt a running command
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// signalContext returns a context that is cancelled on receiving SIGINT or
+// SIGTERM
+//
+// NOTE:
+//  The process exits immediately on receiving a second signal. The returned
+// cancel function stops listening for the signals.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, shutdownSignals...)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "received %s: interrupting; repeat to exit immediately\n", sig)
+			cancel()
+		case <-stopped:
+			return
+		}
+
+		select {
+		case <-signals:
+			os.Exit(130)
+		case <-stopped:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(stopped)
+			cancel()
+		})
+	}
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	goflag "flag"
+	"fmt"
+	"os"
+
+	"github.com/AmitKumarDas/decide/cmd/decide/app"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	// glog flags e.g. -v are made available to decide
+	pflag.CommandLine.AddGoFlagSet(goflag.CommandLine)
+	goflag.Set("logtostderr", "true")
+	goflag.CommandLine.Parse([]string{})
+
+	err := app.NewCommand().Execute()
+	if _, ok := err.(app.ExitError); err != nil && !ok {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	}
+
+	os.Exit(app.ExitCode(err))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
 	"strings"
@@ -42,12 +43,18 @@ func (fn ConfigMapGetterFunc) Get(name string, options metav1.GetOptions) (*core
 // NewConfigMapGetter returns a new instance of ConfigMapGetter that is capable
 // of fetching a ConfigMap from kubernetes cluster
 func NewConfigMapGetter(namespace string) ConfigMapGetter {
+	return NewConfigMapGetterWithContext(context.Background(), namespace)
+}
+
+// NewConfigMapGetterWithContext returns a new instance of ConfigMapGetter
+// whose API calls honour the given context
+func NewConfigMapGetterWithContext(ctx context.Context, namespace string) ConfigMapGetter {
 	return ConfigMapGetterFunc(func(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
 		if len(strings.TrimSpace(name)) == 0 {
 			return nil, fmt.Errorf("missing config map name: failed to get config map")
 		}
 
-		cs, err := NewClientsetGetter().Get()
+		cs, err := NewClientsetGetterWithContext(ctx).Get()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get config map")
 		}
@@ -55,3 +62,77 @@ func NewConfigMapGetter(namespace string) ConfigMapGetter {
 		return cs.CoreV1().ConfigMaps(namespace).Get(name, options)
 	})
 }
+
+// ConfigMapCreator abstracts creating a ConfigMap instance in kubernetes
+// cluster
+type ConfigMapCreator interface {
+	Create(cm *corev1.ConfigMap) (*corev1.ConfigMap, error)
+}
+
+// ConfigMapCreatorFunc is a functional implementation of ConfigMapCreator
+type ConfigMapCreatorFunc func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error)
+
+// Create is an implementation of ConfigMapCreator
+func (fn ConfigMapCreatorFunc) Create(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	return fn(cm)
+}
+
+// NewConfigMapCreator returns a new instance of ConfigMapCreator that is
+// capable of creating a ConfigMap in kubernetes cluster
+func NewConfigMapCreator(namespace string) ConfigMapCreator {
+	return NewConfigMapCreatorWithContext(context.Background(), namespace)
+}
+
+// NewConfigMapCreatorWithContext returns a new instance of ConfigMapCreator
+// whose API calls honour the given context
+func NewConfigMapCreatorWithContext(ctx context.Context, namespace string) ConfigMapCreator {
+	return ConfigMapCreatorFunc(func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		if cm == nil {
+			return nil, fmt.Errorf("nil config map instance: failed to create config map")
+		}
+
+		cs, err := NewClientsetGetterWithContext(ctx).Get()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create config map '%s'", cm.Name)
+		}
+
+		return cs.CoreV1().ConfigMaps(namespace).Create(cm)
+	})
+}
+
+// ConfigMapUpdater abstracts updating a ConfigMap instance found in
+// kubernetes cluster
+type ConfigMapUpdater interface {
+	Update(cm *corev1.ConfigMap) (*corev1.ConfigMap, error)
+}
+
+// ConfigMapUpdaterFunc is a functional implementation of ConfigMapUpdater
+type ConfigMapUpdaterFunc func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error)
+
+// Update is an implementation of ConfigMapUpdater
+func (fn ConfigMapUpdaterFunc) Update(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	return fn(cm)
+}
+
+// NewConfigMapUpdater returns a new instance of ConfigMapUpdater that is
+// capable of updating a ConfigMap found in kubernetes cluster
+func NewConfigMapUpdater(namespace string) ConfigMapUpdater {
+	return NewConfigMapUpdaterWithContext(context.Background(), namespace)
+}
+
+// NewConfigMapUpdaterWithContext returns a new instance of ConfigMapUpdater
+// whose API calls honour the given context
+func NewConfigMapUpdaterWithContext(ctx context.Context, namespace string) ConfigMapUpdater {
+	return ConfigMapUpdaterFunc(func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		if cm == nil {
+			return nil, fmt.Errorf("nil config map instance: failed to update config map")
+		}
+
+		cs, err := NewClientsetGetterWithContext(ctx).Get()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to update config map '%s'", cm.Name)
+		}
+
+		return cs.CoreV1().ConfigMaps(namespace).Update(cm)
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// contextRoundTripper makes every request sent via the wrapped round
+// tripper honour the given context
+//
+// NOTE:
+//  A request in flight is aborted once the context is cancelled or its
+// deadline is exceeded
+type contextRoundTripper struct {
+	ctx context.Context
+	rt  http.RoundTripper
+}
+
+// RoundTrip is an implementation of http.RoundTripper
+func (c contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.rt.RoundTrip(req.WithContext(c.ctx))
+}
+
+// WithContextConfig returns a copy of the given rest config whose requests
+// honour the given context
+//
+// NOTE:
+//  The config is returned as is if the given context can never be cancelled
+func WithContextConfig(ctx context.Context, config *rest.Config) *rest.Config {
+	if ctx == nil || ctx.Done() == nil || config == nil {
+		return config
+	}
+
+	config = rest.CopyConfig(config)
+	wrap := config.WrapTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if wrap != nil {
+			rt = wrap(rt)
+		}
+		return contextRoundTripper{ctx: ctx, rt: rt}
+	}
+	return config
+}
+
+// NewDynamicGetterWithContext returns a DynamicGetter instance whose API
+// calls honour the given context
+func NewDynamicGetterWithContext(ctx context.Context) DynamicGetter {
+	return func() (dynamic.Interface, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to get dynamic client")
+		}
+
+		config, err := NewClientConfigGetter().Get()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get dynamic client")
+		}
+
+		return dynamic.NewForConfig(WithContextConfig(ctx, config))
+	}
+}
+
+// NewClientsetGetterWithContext returns a ClientsetGetter instance whose API
+// calls honour the given context
+func NewClientsetGetterWithContext(ctx context.Context) ClientsetGetter {
+	return ClientsetGetterFunc(func() (*kubernetes.Clientset, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to get kubernetes clientset")
+		}
+
+		config, err := NewClientConfigGetter().Get()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get kubernetes clientset")
+		}
+
+		return kubernetes.NewForConfig(WithContextConfig(ctx, config))
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestWithContextConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	config := &rest.Config{Host: server.URL}
+	if got := WithContextConfig(context.Background(), config); got != config {
+		t.Fatalf("expected same config for a context that is never cancelled: got a copy")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	withCtx := WithContextConfig(ctx, config)
+	if withCtx == config || config.WrapTransport != nil {
+		t.Fatalf("expected a copy of config with its transport wrapped: got the original config modified")
+	}
+
+	rt, err := rest.TransportFor(withCtx)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	client := &http.Client{Transport: rt}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Get(server.URL)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected error after context deadline: got none")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected request to be aborted after context deadline: request is still in flight")
+	}
+}
+
+func TestRetryPolicyDoContext(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialInterval: time.Hour,
+		Factor:          1.0,
+		MaxElapsedTime:  2 * time.Hour,
+		IsRetriable:     func(err error) bool { return true },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- policy.DoContext(ctx, func() error {
+			attempts++
+			return fmt.Errorf("boom")
+		})
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil || attempts != 1 {
+			t.Fatalf("expected error after a single attempt: got error '%v' after '%d' attempts", err, attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected retries to stop once context is cancelled: still retrying")
+	}
+}
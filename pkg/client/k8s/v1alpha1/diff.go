@@ -0,0 +1,276 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ServerManagedFields is the list of field paths that are populated by
+// kubernetes server and hence are not considered while comparing a desired
+// unstructured instance against its live counterpart
+var ServerManagedFields = [][]string{
+	{"metadata", "resourceVersion"},
+	{"metadata", "uid"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "selfLink"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "finalizers"},
+	{"metadata", "ownerReferences"},
+	{"metadata", "initializers"},
+	{"status"},
+}
+
+// LiveOnlyFields is the list of top level fields whose nested fields are
+// considered as differences even if they are found only in the live
+// unstructured instance; this applies only if the top level field is
+// specified in the desired instance
+var LiveOnlyFields = []string{"spec", "data"}
+
+// FieldDiff represents the difference of a single field between a live and
+// a desired unstructured instance
+type FieldDiff struct {
+	// Path of the field e.g. spec.run.tasks[0]
+	Path string `json:"path"`
+	// Live value of the field; nil if this field is added
+	Live interface{} `json:"live,omitempty"`
+	// Desired value of the field; nil if this field is removed
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// IsAdded returns true if this field is not available in live instance
+func (d FieldDiff) IsAdded() bool {
+	return d.Live == nil && d.Desired != nil
+}
+
+// IsRemoved returns true if this field is not available in desired instance
+func (d FieldDiff) IsRemoved() bool {
+	return d.Live != nil && d.Desired == nil
+}
+
+// String returns the human readable representation of this field difference
+func (d FieldDiff) String() string {
+	switch {
+	case d.IsAdded():
+		return fmt.Sprintf("+ %s: %v", d.Path, d.Desired)
+	case d.IsRemoved():
+		return fmt.Sprintf("- %s: %v", d.Path, d.Live)
+	default:
+		return fmt.Sprintf("~ %s: %v => %v", d.Path, d.Live, d.Desired)
+	}
+}
+
+// UnstructuredDiffer abstracts finding the field level differences between a
+// live and a desired unstructured instance
+type UnstructuredDiffer func(live, desired *unstructured.Unstructured) (diffs []FieldDiff)
+
+// DiffUnstructured returns the field level differences between the live and
+// the desired unstructured instances
+//
+// NOTE:
+//  Only the fields specified in the desired instance are compared. Fields
+// found only in the live instance e.g. the ones defaulted by kubernetes
+// server are not considered as differences.
+//
+// NOTE:
+//  Fields populated by kubernetes server are ignored
+//
+// NOTE:
+//  This is an implementation of UnstructuredDiffer
+func DiffUnstructured(live, desired *unstructured.Unstructured) (diffs []FieldDiff) {
+	return diffUnstructured(live, desired, noLiveOnlyField)
+}
+
+// DiffUnstructuredWithLiveOnly returns the field level differences between
+// the live and the desired unstructured instances including the fields found
+// only in the live instance under LiveOnlyFields
+//
+// NOTE:
+//  This is meant to find if a live instance needs to be updated e.g. a key
+// added to the data of a live config map is a difference. Fields found only
+// in the live instance elsewhere e.g. labels added by other controllers are
+// not considered as differences. However, fields defaulted by kubernetes
+// server under the spec of a desired instance e.g. a deployment are
+// considered as differences.
+//
+// NOTE:
+//  Fields populated by kubernetes server are ignored
+//
+// NOTE:
+//  This is an implementation of UnstructuredDiffer
+func DiffUnstructuredWithLiveOnly(live, desired *unstructured.Unstructured) (diffs []FieldDiff) {
+	var specified map[string]interface{}
+	if desired != nil {
+		specified = desired.Object
+	}
+	return diffUnstructured(live, desired, isLiveOnlyField(specified))
+}
+
+// DiffUnstructuredAll returns the field level differences between the two
+// given unstructured instances including the fields found only in the first
+// one
+//
+// NOTE:
+//  This is meant to compare two desired instances e.g. the artifacts of two
+// versions. Use DiffUnstructured to compare a live instance with its desired
+// state.
+//
+// NOTE:
+//  This is an implementation of UnstructuredDiffer
+func DiffUnstructuredAll(old, new *unstructured.Unstructured) (diffs []FieldDiff) {
+	return diffUnstructured(old, new, anyLiveOnlyField)
+}
+
+// liveOnlyFieldPredicate abstracts deciding if the field at the given path
+// is considered as a difference when it is found only in the live instance
+type liveOnlyFieldPredicate func(path string) bool
+
+// noLiveOnlyField ignores every field found only in the live instance
+func noLiveOnlyField(path string) bool {
+	return false
+}
+
+// anyLiveOnlyField considers every field found only in the live instance as
+// a difference
+func anyLiveOnlyField(path string) bool {
+	return true
+}
+
+// isLiveOnlyField returns a predicate that is true if the field at the
+// given path is nested under one of LiveOnlyFields that is specified in the
+// given desired object
+func isLiveOnlyField(desired map[string]interface{}) liveOnlyFieldPredicate {
+	return func(path string) bool {
+		top := path
+		if idx := strings.IndexAny(path, ".["); idx != -1 {
+			top = path[:idx]
+		}
+		if _, found := desired[top]; !found {
+			return false
+		}
+		for _, field := range LiveOnlyFields {
+			if top == field {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// diffUnstructured returns the field level differences between the live and
+// the desired unstructured instances; fields found only in the live instance
+// are considered only if the given predicate returns true
+func diffUnstructured(live, desired *unstructured.Unstructured, liveOnly liveOnlyFieldPredicate) (diffs []FieldDiff) {
+	var liveObj, desiredObj map[string]interface{}
+
+	if live != nil {
+		liveObj = withoutServerManagedFields(live).Object
+	}
+
+	if desired != nil {
+		desiredObj = withoutServerManagedFields(desired).Object
+	}
+
+	return diffValues("", liveObj, desiredObj, liveOnly)
+}
+
+// withoutServerManagedFields returns a copy of the given unstructured
+// instance without the fields populated by kubernetes server
+func withoutServerManagedFields(given *unstructured.Unstructured) *unstructured.Unstructured {
+	copied := given.DeepCopy()
+	for _, fields := range ServerManagedFields {
+		unstructured.RemoveNestedField(copied.Object, fields...)
+	}
+
+	// empty metadata maps are same as missing ones
+	for _, fields := range [][]string{{"metadata", "labels"}, {"metadata", "annotations"}} {
+		m, found, _ := unstructured.NestedMap(copied.Object, fields...)
+		if found && len(m) == 0 {
+			unstructured.RemoveNestedField(copied.Object, fields...)
+		}
+	}
+
+	return copied
+}
+
+// diffValues recursively finds the differences between live and desired
+// values
+func diffValues(path string, live, desired interface{}, liveOnly liveOnlyFieldPredicate) (diffs []FieldDiff) {
+	liveMap, isLiveMap := live.(map[string]interface{})
+	desiredMap, isDesiredMap := desired.(map[string]interface{})
+	if isLiveMap && isDesiredMap {
+		return diffMaps(path, liveMap, desiredMap, liveOnly)
+	}
+
+	liveList, isLiveList := live.([]interface{})
+	desiredList, isDesiredList := desired.([]interface{})
+	if isLiveList && isDesiredList && len(liveList) == len(desiredList) {
+		for idx := range liveList {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, idx), liveList[idx], desiredList[idx], liveOnly)...)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		diffs = append(diffs, FieldDiff{Path: path, Live: live, Desired: desired})
+	}
+
+	return
+}
+
+// diffMaps finds the differences between live and desired maps in a sorted
+// order of keys; keys found only in the live map are considered only if the
+// given predicate returns true
+func diffMaps(path string, live, desired map[string]interface{}, liveOnly liveOnlyFieldPredicate) (diffs []FieldDiff) {
+	keys := map[string]bool{}
+	for key := range desired {
+		keys[key] = true
+	}
+
+	for key := range live {
+		if liveOnly(fieldPath(path, key)) {
+			keys[key] = true
+		}
+	}
+
+	var sortedKeys []string
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		diffs = append(diffs, diffValues(fieldPath(path, key), live[key], desired[key], liveOnly)...)
+	}
+
+	return
+}
+
+// fieldPath returns the path of the given key nested under the given path
+func fieldPath(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
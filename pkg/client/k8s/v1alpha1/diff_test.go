@@ -0,0 +1,245 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// unstructuredFrom returns the unstructured instance of the given object
+func unstructuredFrom(obj map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func namespaceObject(labels map[string]interface{}, spec map[string]interface{}) map[string]interface{} {
+	metadata := map[string]interface{}{"name": "openebs"}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+	obj := map[string]interface{}{"apiVersion": "v1", "kind": "Namespace", "metadata": metadata}
+	if spec != nil {
+		obj["spec"] = spec
+	}
+	return obj
+}
+
+func TestDiffUnstructured(t *testing.T) {
+	tests := map[string]struct {
+		live        map[string]interface{}
+		desired     map[string]interface{}
+		expectPaths []string
+	}{
+		"same": {
+			live:    namespaceObject(map[string]interface{}{"app": "openebs"}, nil),
+			desired: namespaceObject(map[string]interface{}{"app": "openebs"}, nil),
+		},
+		"server defaulted namespace finalizers": {
+			live:    namespaceObject(nil, map[string]interface{}{"finalizers": []interface{}{"kubernetes"}}),
+			desired: namespaceObject(nil, nil),
+		},
+		"server defaulted deployment fields": {
+			live: map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": map[string]interface{}{"name": "maya", "namespace": "openebs", "uid": "1234", "resourceVersion": "10"},
+				"spec": map[string]interface{}{
+					"replicas":                int64(1),
+					"revisionHistoryLimit":    int64(10),
+					"progressDeadlineSeconds": int64(600),
+					"template": map[string]interface{}{"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "maya", "image": "openebs/m-apiserver:0.7.0", "imagePullPolicy": "IfNotPresent", "terminationMessagePath": "/dev/termination-log"},
+						},
+						"dnsPolicy": "ClusterFirst",
+					}},
+				},
+				"status": map[string]interface{}{"replicas": int64(1)},
+			},
+			desired: map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": map[string]interface{}{"name": "maya", "namespace": "openebs"},
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+					"template": map[string]interface{}{"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "maya", "image": "openebs/m-apiserver:0.7.0"},
+						},
+					}},
+				},
+			},
+		},
+		"changed value within a list": {
+			live: map[string]interface{}{"kind": "CASTemplate", "spec": map[string]interface{}{
+				"run": map[string]interface{}{"tasks": []interface{}{"a", "b"}},
+			}},
+			desired: map[string]interface{}{"kind": "CASTemplate", "spec": map[string]interface{}{
+				"run": map[string]interface{}{"tasks": []interface{}{"a", "c"}},
+			}},
+			expectPaths: []string{"spec.run.tasks[1]"},
+		},
+		"list of different length": {
+			live: map[string]interface{}{"kind": "CASTemplate", "spec": map[string]interface{}{
+				"run": map[string]interface{}{"tasks": []interface{}{"a"}},
+			}},
+			desired: map[string]interface{}{"kind": "CASTemplate", "spec": map[string]interface{}{
+				"run": map[string]interface{}{"tasks": []interface{}{"a", "b"}},
+			}},
+			expectPaths: []string{"spec.run.tasks"},
+		},
+		"added label": {
+			live:        namespaceObject(nil, nil),
+			desired:     namespaceObject(map[string]interface{}{"app": "openebs"}, nil),
+			expectPaths: []string{"metadata.labels"},
+		},
+		"label only in live": {
+			live:    namespaceObject(map[string]interface{}{"team": "storage"}, nil),
+			desired: namespaceObject(map[string]interface{}{}, nil),
+		},
+		"missing live": {
+			desired:     namespaceObject(nil, nil),
+			expectPaths: []string{"apiVersion", "kind", "metadata"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var live *unstructured.Unstructured
+			if mock.live != nil {
+				live = unstructuredFrom(mock.live)
+			}
+
+			diffs := DiffUnstructured(live, unstructuredFrom(mock.desired))
+			if len(diffs) != len(mock.expectPaths) {
+				t.Fatalf("expected diffs at '%v': got '%v'", mock.expectPaths, diffs)
+			}
+			for idx, diff := range diffs {
+				if diff.Path != mock.expectPaths[idx] {
+					t.Fatalf("expected diff at '%s': got '%s'", mock.expectPaths[idx], diff.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffUnstructuredAll(t *testing.T) {
+	old := unstructuredFrom(namespaceObject(map[string]interface{}{"team": "storage"}, nil))
+	new := unstructuredFrom(namespaceObject(nil, nil))
+
+	if diffs := DiffUnstructured(old, new); len(diffs) != 0 {
+		t.Fatalf("expected no diff of a field found only in live: got '%v'", diffs)
+	}
+
+	diffs := DiffUnstructuredAll(old, new)
+	if len(diffs) != 1 || !diffs[0].IsRemoved() || diffs[0].Path != "metadata.labels" {
+		t.Fatalf("expected removed 'metadata.labels': got '%v'", diffs)
+	}
+}
+
+func TestDiffUnstructuredWithLiveOnly(t *testing.T) {
+	configMap := func(data map[string]interface{}, labels map[string]interface{}) map[string]interface{} {
+		metadata := map[string]interface{}{"name": "openebs-install", "namespace": "openebs"}
+		if labels != nil {
+			metadata["labels"] = labels
+		}
+		obj := map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": metadata}
+		if data != nil {
+			obj["data"] = data
+		}
+		return obj
+	}
+
+	tests := map[string]struct {
+		live          map[string]interface{}
+		desired       map[string]interface{}
+		expectPaths   []string
+		expectRemoved bool
+	}{
+		"same": {
+			live:    configMap(map[string]interface{}{"a": "1"}, nil),
+			desired: configMap(map[string]interface{}{"a": "1"}, nil),
+		},
+		"data key only in live": {
+			live:          configMap(map[string]interface{}{"a": "1", "b": "2"}, nil),
+			desired:       configMap(map[string]interface{}{"a": "1"}, nil),
+			expectPaths:   []string{"data.b"},
+			expectRemoved: true,
+		},
+		"spec field only in live": {
+			live: map[string]interface{}{"kind": "CASTemplate", "spec": map[string]interface{}{
+				"run": map[string]interface{}{"tasks": []interface{}{"a"}}, "output": "b",
+			}},
+			desired: map[string]interface{}{"kind": "CASTemplate", "spec": map[string]interface{}{
+				"run": map[string]interface{}{"tasks": []interface{}{"a"}},
+			}},
+			expectPaths:   []string{"spec.output"},
+			expectRemoved: true,
+		},
+		"label only in live": {
+			live:    configMap(map[string]interface{}{"a": "1"}, map[string]interface{}{"team": "storage"}),
+			desired: configMap(map[string]interface{}{"a": "1"}, nil),
+		},
+		"spec not specified in desired": {
+			live:    namespaceObject(nil, map[string]interface{}{"finalizers": []interface{}{"kubernetes"}}),
+			desired: namespaceObject(nil, nil),
+		},
+		"server managed fields only in live": {
+			live: map[string]interface{}{"kind": "CASTemplate", "metadata": map[string]interface{}{"uid": "1234"},
+				"spec": map[string]interface{}{"a": "1"}, "status": map[string]interface{}{"phase": "done"}},
+			desired: map[string]interface{}{"kind": "CASTemplate", "metadata": map[string]interface{}{},
+				"spec": map[string]interface{}{"a": "1"}},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			live, desired := unstructuredFrom(mock.live), unstructuredFrom(mock.desired)
+			if diffs := DiffUnstructured(live, desired); len(diffs) != 0 {
+				t.Fatalf("expected no diff of fields found only in live: got '%v'", diffs)
+			}
+
+			diffs := DiffUnstructuredWithLiveOnly(live, desired)
+			if len(diffs) != len(mock.expectPaths) {
+				t.Fatalf("expected diffs at '%v': got '%v'", mock.expectPaths, diffs)
+			}
+			for idx, diff := range diffs {
+				if diff.Path != mock.expectPaths[idx] || diff.IsRemoved() != mock.expectRemoved {
+					t.Fatalf("expected diff at '%s' with removed '%t': got '%s'", mock.expectPaths[idx], mock.expectRemoved, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldDiffString(t *testing.T) {
+	tests := map[string]struct {
+		diff     FieldDiff
+		expected string
+	}{
+		"added":   {diff: FieldDiff{Path: "spec.replicas", Desired: 3}, expected: "+ spec.replicas: 3"},
+		"removed": {diff: FieldDiff{Path: "spec.replicas", Live: 3}, expected: "- spec.replicas: 3"},
+		"changed": {diff: FieldDiff{Path: "spec.replicas", Live: 1, Desired: 3}, expected: "~ spec.replicas: 1 => 3"},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := mock.diff.String(); got != mock.expected {
+				t.Fatalf("expected '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+)
+
+// eventRecorder records events in kubernetes cluster as soon as they occur
+//
+// NOTE:
+//  This is an implementation of record.EventRecorder. Unlike the event
+// recorder of client-go, an event is created before the call returns. Hence,
+// no event is lost if the process exits right after & there is no background
+// routine to be shut down. Similar events are not aggregated.
+type eventRecorder struct {
+	source corev1.EventSource
+	create func(event *corev1.Event) (*corev1.Event, error)
+}
+
+// NewEventRecorder returns a new instance of record.EventRecorder that records
+// the events of the given component in kubernetes cluster
+func NewEventRecorder(component string) record.EventRecorder {
+	return &eventRecorder{
+		source: corev1.EventSource{Component: component},
+		create: func(event *corev1.Event) (*corev1.Event, error) {
+			cs, err := NewClientsetGetter().Get()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to create event '%s'", event.Name)
+			}
+			return cs.CoreV1().Events(event.Namespace).Create(event)
+		},
+	}
+}
+
+// record creates the event corresponding to the given information
+//
+// NOTE:
+//  Failure to record an event is logged since recording an event should not
+// fail the caller
+func (r *eventRecorder) record(object runtime.Object, annotations map[string]string, timestamp metav1.Time, eventtype, reason, message string) {
+	if eventtype != corev1.EventTypeNormal && eventtype != corev1.EventTypeWarning {
+		utilruntime.HandleError(fmt.Errorf("unsupported event type '%s': failed to record event '%s'", eventtype, reason))
+		return
+	}
+
+	ref, err := reference.GetReference(scheme.Scheme, object)
+	if err != nil {
+		utilruntime.HandleError(errors.Wrapf(err, "failed to record event '%s'", reason))
+		return
+	}
+
+	namespace := ref.Namespace
+	if len(namespace) == 0 {
+		namespace = metav1.NamespaceDefault
+	}
+
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%v.%x", ref.Name, timestamp.UnixNano()),
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		InvolvedObject: *ref,
+		Reason:         reason,
+		Message:        message,
+		FirstTimestamp: timestamp,
+		LastTimestamp:  timestamp,
+		Count:          1,
+		Type:           eventtype,
+		Source:         r.source,
+	}
+
+	_, err = r.create(event)
+	if err != nil {
+		utilruntime.HandleError(errors.Wrapf(err, "failed to record event '%s' of '%s/%s'", reason, namespace, ref.Name))
+	}
+}
+
+// Event is an implementation of record.EventRecorder
+func (r *eventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.record(object, nil, metav1.Now(), eventtype, reason, message)
+}
+
+// Eventf is an implementation of record.EventRecorder
+func (r *eventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, nil, metav1.Now(), eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// PastEventf is an implementation of record.EventRecorder
+func (r *eventRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, nil, timestamp, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf is an implementation of record.EventRecorder
+func (r *eventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, annotations, metav1.Now(), eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventRecorder(t *testing.T) {
+	tests := map[string]struct {
+		namespace       string
+		eventType       string
+		createErr       error
+		expectCreated   bool
+		expectNamespace string
+	}{
+		"normal event":           {namespace: "openebs", eventType: corev1.EventTypeNormal, expectCreated: true, expectNamespace: "openebs"},
+		"warning event":          {namespace: "openebs", eventType: corev1.EventTypeWarning, expectCreated: true, expectNamespace: "openebs"},
+		"unsupported event type": {namespace: "openebs", eventType: "Fatal", expectCreated: false},
+		"create fails":           {namespace: "openebs", eventType: corev1.EventTypeNormal, createErr: fmt.Errorf("boom"), expectCreated: true, expectNamespace: "openebs"},
+		"no namespace":           {eventType: corev1.EventTypeNormal, expectCreated: true, expectNamespace: "default"},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "install-config", Namespace: mock.namespace}}
+			cm.Kind = "ConfigMap"
+			cm.APIVersion = "v1"
+			var created []*corev1.Event
+			recorder := &eventRecorder{
+				source: corev1.EventSource{Component: "fake-component"},
+				create: func(event *corev1.Event) (*corev1.Event, error) {
+					created = append(created, event)
+					return event, mock.createErr
+				},
+			}
+
+			// the event is expected to be created before the call returns
+			recorder.Eventf(cm, mock.eventType, "Installed", "installed %d resources", 3)
+			if !mock.expectCreated {
+				if len(created) != 0 {
+					t.Fatalf("expected no event to be created: got '%v'", created)
+				}
+				return
+			}
+
+			if len(created) != 1 {
+				t.Fatalf("expected one event to be created: got '%d'", len(created))
+			}
+			event := created[0]
+			if event.Namespace != mock.expectNamespace || event.InvolvedObject.Name != "install-config" || event.InvolvedObject.Kind != "ConfigMap" {
+				t.Fatalf("expected event of '%s/install-config': got '%s' of '%+v'", mock.expectNamespace, event.Namespace, event.InvolvedObject)
+			}
+			if event.Type != mock.eventType || event.Reason != "Installed" || event.Message != "installed 3 resources" {
+				t.Fatalf("expected '%s' event 'Installed' with message 'installed 3 resources': got '%s' event '%s' with message '%s'",
+					mock.eventType, event.Type, event.Reason, event.Message)
+			}
+			if event.Source.Component != "fake-component" || event.Count != 1 {
+				t.Fatalf("expected event from 'fake-component' with count '1': got '%+v'", event)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ApplyResult is a typed string to represent the outcome of applying a
+// resource
+type ApplyResult string
+
+const (
+	// ApplyResultSuccess indicates the resource was applied successfully
+	ApplyResultSuccess ApplyResult = "success"
+	// ApplyResultFailure indicates the resource could not be applied
+	ApplyResultFailure ApplyResult = "failure"
+)
+
+// applyActionUnknown is used as the action of a failed apply that could not
+// determine if the resource needs to be created or updated
+const applyActionUnknown = "unknown"
+
+var (
+	// ResourceApplyTotal is the number of resources applied into kubernetes
+	// cluster by their action & result
+	ResourceApplyTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "openebs",
+			Subsystem: "resource",
+			Name:      "apply_total",
+			Help:      "Number of resources applied into kubernetes cluster by action and result.",
+		},
+		[]string{"group", "version", "resource", "action", "result"},
+	)
+
+	// ResourceApplyDuration is the time taken to apply a resource into
+	// kubernetes cluster including the retries
+	ResourceApplyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "openebs",
+			Subsystem: "resource",
+			Name:      "apply_duration_seconds",
+			Help:      "Time taken to apply a resource into kubernetes cluster including retries.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"group", "version", "resource"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(ResourceApplyTotal, ResourceApplyDuration)
+}
+
+// observeResourceApply records the outcome of applying a resource of the
+// given group version resource
+func observeResourceApply(gvr schema.GroupVersionResource, action ApplyAction, err error, duration time.Duration) {
+	result := ApplyResultSuccess
+	if err != nil {
+		result = ApplyResultFailure
+	}
+
+	actionLabel := string(action)
+	if len(actionLabel) == 0 {
+		actionLabel = applyActionUnknown
+	}
+
+	ResourceApplyTotal.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource, actionLabel, string(result)).Inc()
+	ResourceApplyDuration.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource).Observe(duration.Seconds())
+}
+
+// instrumentResourceActionApplier returns a ResourceActionApplier that
+// records the metrics of every apply made via the given applier
+func instrumentResourceActionApplier(gvr schema.GroupVersionResource, apply ResourceActionApplier) ResourceActionApplier {
+	return func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, ApplyAction, error) {
+		start := time.Now()
+		resource, action, err := apply(obj, subresources...)
+		observeResourceApply(gvr, action, err, time.Since(start))
+		return resource, action, err
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestInstrumentResourceActionApplier(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "openebs.io", Version: "v1alpha1", Resource: "castemplates"}
+
+	tests := map[string]struct {
+		action       ApplyAction
+		err          error
+		expectAction string
+		expectResult ApplyResult
+	}{
+		"created":        {action: ApplyActionCreated, expectAction: "created", expectResult: ApplyResultSuccess},
+		"updated":        {action: ApplyActionUpdated, expectAction: "updated", expectResult: ApplyResultSuccess},
+		"unchanged":      {action: ApplyActionUnchanged, expectAction: "unchanged", expectResult: ApplyResultSuccess},
+		"failed create":  {action: ApplyActionCreated, err: fmt.Errorf("boom"), expectAction: "created", expectResult: ApplyResultFailure},
+		"failed unknown": {err: fmt.Errorf("boom"), expectAction: applyActionUnknown, expectResult: ApplyResultFailure},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			counter := ResourceApplyTotal.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource, mock.expectAction, string(mock.expectResult))
+			before := testutil.ToFloat64(counter)
+
+			apply := instrumentResourceActionApplier(gvr, func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, ApplyAction, error) {
+				return obj, mock.action, mock.err
+			})
+
+			_, action, err := apply(&unstructured.Unstructured{})
+			if action != mock.action || err != mock.err {
+				t.Fatalf("expected action '%s' and error '%v': got action '%s' and error '%v'", mock.action, mock.err, action, err)
+			}
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Fatalf("expected apply count to increase by 1: got '%v'", got)
+			}
+		})
+	}
+}
+
+func TestResourceApplyDuration(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+
+	sampleCount := func() uint64 {
+		var metric dto.Metric
+		err := ResourceApplyDuration.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource).(prometheus.Histogram).Write(&metric)
+		if err != nil {
+			t.Fatalf("expected no error: got '%v'", err)
+		}
+		return metric.GetHistogram().GetSampleCount()
+	}
+	before := sampleCount()
+
+	apply := instrumentResourceActionApplier(gvr, func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, ApplyAction, error) {
+		return obj, ApplyActionCreated, nil
+	})
+	apply(&unstructured.Unstructured{})
+
+	if got := sampleCount() - before; got != 1 {
+		t.Fatalf("expected apply duration samples to increase by 1: got '%d'", got)
+	}
+}
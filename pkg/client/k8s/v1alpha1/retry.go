@@ -0,0 +1,148 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ErrorClassifier abstracts deciding if an error is worth a retry
+type ErrorClassifier func(err error) (retry bool)
+
+// IsTransientError returns true if the given error is a temporary failure of
+// kubernetes server e.g. timeouts, throttling, 5xx responses or broken
+// connections
+//
+// NOTE:
+//  This is an implementation of ErrorClassifier
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	err = errors.Cause(err)
+	if apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Code >= 500 {
+		return true
+	}
+
+	return utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err)
+}
+
+// IsTransientOrConflictError returns true if the given error is either a
+// transient error or a conflict due to a stale resource
+//
+// NOTE:
+//  This is an implementation of ErrorClassifier
+func IsTransientOrConflictError(err error) bool {
+	return IsTransientError(err) || apierrors.IsConflict(errors.Cause(err))
+}
+
+// RetryPolicy determines if and when a failed operation is retried
+type RetryPolicy struct {
+	// InitialInterval is the wait before the first retry
+	InitialInterval time.Duration
+	// Factor by which the wait is multiplied after every retry
+	Factor float64
+	// Jitter is the maximum fraction of the wait that is added randomly to
+	// the wait
+	Jitter float64
+	// MaxInterval caps the wait between two retries
+	MaxInterval time.Duration
+	// MaxElapsedTime is the time after which no more retries are made
+	MaxElapsedTime time.Duration
+	// IsRetriable decides if an error is worth a retry
+	IsRetriable ErrorClassifier
+}
+
+// DefaultRetryPolicy returns the retry policy that retries transient errors
+// as well as conflicts for up to two minutes
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		InitialInterval: 500 * time.Millisecond,
+		Factor:          2.0,
+		Jitter:          0.2,
+		MaxInterval:     15 * time.Second,
+		MaxElapsedTime:  2 * time.Minute,
+		IsRetriable:     IsTransientOrConflictError,
+	}
+}
+
+// Do executes the given operation till it succeeds, fails with an error
+// that is not retriable or the maximum elapsed time is reached
+//
+// NOTE:
+//  A nil retry policy executes the operation only once
+func (p *RetryPolicy) Do(operation func() error) error {
+	return p.DoContext(context.Background(), operation)
+}
+
+// DoContext executes the given operation till it succeeds, fails with an
+// error that is not retriable, the maximum elapsed time is reached or the
+// given context is done
+//
+// NOTE:
+//  A nil retry policy executes the operation only once
+func (p *RetryPolicy) DoContext(ctx context.Context, operation func() error) error {
+	if p == nil || p.IsRetriable == nil {
+		return operation()
+	}
+
+	start := time.Now()
+	interval := p.InitialInterval
+	for {
+		err := operation()
+		if err == nil || !p.IsRetriable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := wait.Jitter(interval, p.Jitter)
+		if seconds, ok := apierrors.SuggestsClientDelay(errors.Cause(err)); ok && time.Duration(seconds)*time.Second > delay {
+			// honour the delay suggested by kubernetes server
+			delay = time.Duration(seconds) * time.Second
+		}
+
+		if time.Since(start)+delay > p.MaxElapsedTime {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		interval = time.Duration(float64(interval) * p.Factor)
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
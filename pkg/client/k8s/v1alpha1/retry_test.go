@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsTransientError(t *testing.T) {
+	resource := schema.GroupResource{Group: "openebs.io", Resource: "castemplates"}
+	tests := map[string]struct {
+		err             error
+		expectTransient bool
+		expectConflict  bool
+	}{
+		"nil error":           {err: nil},
+		"plain error":         {err: fmt.Errorf("boom")},
+		"not found":           {err: apierrors.NewNotFound(resource, "cast")},
+		"invalid":             {err: apierrors.NewBadRequest("invalid")},
+		"server timeout":      {err: apierrors.NewServerTimeout(resource, "create", 1), expectTransient: true},
+		"too many requests":   {err: apierrors.NewTooManyRequests("throttled", 1), expectTransient: true},
+		"internal error":      {err: apierrors.NewInternalError(fmt.Errorf("boom")), expectTransient: true},
+		"service unavailable": {err: apierrors.NewServiceUnavailable("unavailable"), expectTransient: true},
+		"wrapped timeout":     {err: errors.Wrap(apierrors.NewTimeoutError("timeout", 1), "failed to apply"), expectTransient: true},
+		"eof":                 {err: io.EOF, expectTransient: true},
+		"conflict":            {err: apierrors.NewConflict(resource, "cast", fmt.Errorf("stale")), expectConflict: true},
+		"wrapped conflict":    {err: errors.Wrap(apierrors.NewConflict(resource, "cast", fmt.Errorf("stale")), "failed to apply"), expectConflict: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := IsTransientError(mock.err); got != mock.expectTransient {
+				t.Fatalf("expected transient '%t': got '%t'", mock.expectTransient, got)
+			}
+			expectRetry := mock.expectTransient || mock.expectConflict
+			if got := IsTransientOrConflictError(mock.err); got != expectRetry {
+				t.Fatalf("expected transient or conflict '%t': got '%t'", expectRetry, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := apierrors.NewServiceUnavailable("unavailable")
+	policy := &RetryPolicy{
+		InitialInterval: time.Millisecond,
+		Factor:          2.0,
+		MaxInterval:     4 * time.Millisecond,
+		MaxElapsedTime:  time.Second,
+		IsRetriable:     IsTransientError,
+	}
+
+	tests := map[string]struct {
+		policy         *RetryPolicy
+		errs           []error
+		expectAttempts int
+		expectErr      bool
+	}{
+		"success at first attempt": {
+			policy:         policy,
+			expectAttempts: 1,
+		},
+		"success after transient errors": {
+			policy:         policy,
+			errs:           []error{transient, transient},
+			expectAttempts: 3,
+		},
+		"error that is not retriable": {
+			policy:         policy,
+			errs:           []error{transient, fmt.Errorf("boom")},
+			expectAttempts: 2,
+			expectErr:      true,
+		},
+		"max elapsed time is reached before the first retry": {
+			policy: &RetryPolicy{
+				InitialInterval: time.Second,
+				Factor:          1.0,
+				MaxElapsedTime:  time.Millisecond,
+				IsRetriable:     IsTransientError,
+			},
+			errs:           []error{transient, transient},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+		"nil policy": {
+			errs:           []error{transient},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+		"policy without classifier": {
+			policy:         &RetryPolicy{InitialInterval: time.Millisecond},
+			errs:           []error{transient},
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			err := mock.policy.Do(func() error {
+				attempts++
+				if attempts > len(mock.errs) {
+					return nil
+				}
+				return mock.errs[attempts-1]
+			})
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if attempts != mock.expectAttempts {
+				t.Fatalf("expected '%d' attempts: got '%d'", mock.expectAttempts, attempts)
+			}
+		})
+	}
+}
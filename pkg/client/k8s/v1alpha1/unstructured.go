@@ -30,6 +30,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -77,12 +78,18 @@ type ResourceCreator func(obj *unstructured.Unstructured, subresources ...string
 // NewResourceCreator returns a new instance of ResourceCreator that is
 // capable of creating a resource in kubernetes cluster
 func NewResourceCreator(gvr schema.GroupVersionResource, namespace string) ResourceCreator {
+	return NewResourceCreatorWithContext(context.Background(), gvr, namespace)
+}
+
+// NewResourceCreatorWithContext returns a new instance of ResourceCreator
+// whose API calls honour the given context
+func NewResourceCreatorWithContext(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ResourceCreator {
 	return func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
 		if obj == nil {
 			return nil, fmt.Errorf("nil resource instance: failed to create resource")
 		}
 
-		dynamic, err := NewDynamicGetter()()
+		dynamic, err := NewDynamicGetterWithContext(ctx)()
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create resource")
 		}
@@ -98,12 +105,18 @@ type ResourceGetter func(name string, options metav1.GetOptions, subresources ..
 // NewResourceGetter returns a new instance of ResourceGetter that is capable
 // of fetching an unstructured instance from kubernetes cluster
 func NewResourceGetter(gvr schema.GroupVersionResource, namespace string) ResourceGetter {
+	return NewResourceGetterWithContext(context.Background(), gvr, namespace)
+}
+
+// NewResourceGetterWithContext returns a new instance of ResourceGetter whose
+// API calls honour the given context
+func NewResourceGetterWithContext(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ResourceGetter {
 	return func(name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
 		if len(strings.TrimSpace(name)) == 0 {
 			return nil, fmt.Errorf("missing resource name: failed to get resource")
 		}
 
-		dynamic, err := NewDynamicGetter()()
+		dynamic, err := NewDynamicGetterWithContext(ctx)()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get resource '%s'", name)
 		}
@@ -119,12 +132,18 @@ type ResourceUpdater func(obj *unstructured.Unstructured, subresources ...string
 // NewResourceUpdater returns a new instance of ResourceUpdater that is capable
 // of updating an unstructured instance found in kubernetes cluster
 func NewResourceUpdater(gvr schema.GroupVersionResource, namespace string) ResourceUpdater {
+	return NewResourceUpdaterWithContext(context.Background(), gvr, namespace)
+}
+
+// NewResourceUpdaterWithContext returns a new instance of ResourceUpdater
+// whose API calls honour the given context
+func NewResourceUpdaterWithContext(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ResourceUpdater {
 	return func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
 		if obj == nil {
 			return nil, fmt.Errorf("nil resource instance: failed to update resource")
 		}
 
-		dynamic, err := NewDynamicGetter()()
+		dynamic, err := NewDynamicGetterWithContext(ctx)()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to update resource '%s'", obj.GetName())
 		}
@@ -133,22 +152,103 @@ func NewResourceUpdater(gvr schema.GroupVersionResource, namespace string) Resou
 	}
 }
 
+// ResourceDeleter abstracts deleting an unstructured instance from kubernetes
+// cluster
+type ResourceDeleter func(name string, options *metav1.DeleteOptions, subresources ...string) error
+
+// NewResourceDeleter returns a new instance of ResourceDeleter that is capable
+// of deleting an unstructured instance from kubernetes cluster
+func NewResourceDeleter(gvr schema.GroupVersionResource, namespace string) ResourceDeleter {
+	return NewResourceDeleterWithContext(context.Background(), gvr, namespace)
+}
+
+// NewResourceDeleterWithContext returns a new instance of ResourceDeleter
+// whose API calls honour the given context
+func NewResourceDeleterWithContext(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ResourceDeleter {
+	return func(name string, options *metav1.DeleteOptions, subresources ...string) error {
+		if len(strings.TrimSpace(name)) == 0 {
+			return fmt.Errorf("missing resource name: failed to delete resource")
+		}
+
+		dynamic, err := NewDynamicGetterWithContext(ctx)()
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete resource '%s'", name)
+		}
+
+		return dynamic.Resource(gvr).Namespace(namespace).Delete(name, options, subresources...)
+	}
+}
+
+// ResourceLister abstracts listing unstructured instances from kubernetes
+// cluster
+type ResourceLister func(options metav1.ListOptions) (*unstructured.UnstructuredList, error)
+
+// NewResourceLister returns a new instance of ResourceLister that is capable
+// of listing unstructured instances from kubernetes cluster
+//
+// NOTE:
+//  An empty namespace lists the namespaced resources across all namespaces
+func NewResourceLister(gvr schema.GroupVersionResource, namespace string) ResourceLister {
+	return NewResourceListerWithContext(context.Background(), gvr, namespace)
+}
+
+// NewResourceListerWithContext returns a new instance of ResourceLister
+// whose API calls honour the given context
+func NewResourceListerWithContext(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ResourceLister {
+	return func(options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+		dynamic, err := NewDynamicGetterWithContext(ctx)()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list resources '%s'", gvr.Resource)
+		}
+
+		return dynamic.Resource(gvr).Namespace(namespace).List(options)
+	}
+}
+
 // ResourceApplyOptions is used during a resource's apply operation
 type ResourceApplyOptions struct {
+	// Context stops the retries once it is done; retries are not bound to
+	// any context if this is nil
+	Context context.Context
 	Getter  ResourceGetter
 	Creator ResourceCreator
 	Updater ResourceUpdater
+	// RetryPolicy is used to retry a failed apply; a failed apply is not
+	// retried if this is nil
+	//
+	// NOTE:
+	//  Every retry re-reads the resource before applying it again. This
+	// resolves conflicts due to a stale resource.
+	RetryPolicy *RetryPolicy
 }
 
-// ResourceApplier abstracts applying an unstructured instance that may or may
-// not be available in kubernetes cluster
-type ResourceApplier func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error)
+// ApplyAction is a typed string to represent the action taken while applying
+// a resource
+type ApplyAction string
+
+const (
+	// ApplyActionCreated indicates the resource was created
+	ApplyActionCreated ApplyAction = "created"
+	// ApplyActionUpdated indicates the resource was updated
+	ApplyActionUpdated ApplyAction = "updated"
+	// ApplyActionUnchanged indicates the resource was already in its desired
+	// state
+	ApplyActionUnchanged ApplyAction = "unchanged"
+)
 
-// newResourceApplier returns a new instance of ResourceApplier that is capable
-// of applying an unstructured instance that may or may not be available in
-// kubernetes cluster
-func newResourceApplier(options ResourceApplyOptions) ResourceApplier {
-	return func(obj *unstructured.Unstructured, subresources ...string) (resource *unstructured.Unstructured, err error) {
+// ResourceActionApplier abstracts applying an unstructured instance that may
+// or may not be available in kubernetes cluster and reports the action that
+// was taken
+type ResourceActionApplier func(obj *unstructured.Unstructured, subresources ...string) (resource *unstructured.Unstructured, action ApplyAction, err error)
+
+// newResourceActionApplier returns a new instance of ResourceActionApplier
+// that is capable of applying an unstructured instance that may or may not be
+// available in kubernetes cluster
+//
+// NOTE:
+//  A resource that does not differ from its live counterpart is not updated
+func newResourceActionApplier(options ResourceApplyOptions) ResourceActionApplier {
+	return func(obj *unstructured.Unstructured, subresources ...string) (resource *unstructured.Unstructured, action ApplyAction, err error) {
 		if options.Getter == nil {
 			err = fmt.Errorf("nil resource getter instance: failed to apply resource")
 			return
@@ -169,28 +269,91 @@ func newResourceApplier(options ResourceApplyOptions) ResourceApplier {
 			return
 		}
 
-		resource, err = options.Getter(obj.GetName(), metav1.GetOptions{})
-		if apierrors.IsNotFound(err) {
-			// create if not found
-			return options.Creator(obj, subresources...)
-		} else {
-			// some genuine error at kubernetes server
-			err = errors.Wrapf(err, "failed to apply resource '%s'", obj.GetName())
-			return
+		ctx := options.Context
+		if ctx == nil {
+			ctx = context.Background()
 		}
 
-		return options.Updater(obj, subresources...)
+		err = options.RetryPolicy.DoContext(ctx, func() (err error) {
+			resource, action, err = applyResource(options, obj, subresources...)
+			return
+		})
+		return
 	}
 }
 
-// NewResourceApplier returns a new instance of ResourceApplier that is capable
-// of applying any resource into kubernetes cluster
-func NewResourceApplier(gvr schema.GroupVersionResource, namespace string) ResourceApplier {
+// applyResource makes a single attempt to create or update the given
+// unstructured instance based on its availability in kubernetes cluster
+func applyResource(options ResourceApplyOptions, obj *unstructured.Unstructured, subresources ...string) (resource *unstructured.Unstructured, action ApplyAction, err error) {
+	resource, err = options.Getter(obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// create if not found
+		resource, err = options.Creator(obj, subresources...)
+		return resource, ApplyActionCreated, err
+	}
+
+	if err != nil {
+		// some genuine error at kubernetes server
+		err = errors.Wrapf(err, "failed to apply resource '%s'", obj.GetName())
+		return
+	}
+
+	if len(DiffUnstructuredWithLiveOnly(resource, obj)) == 0 {
+		return resource, ApplyActionUnchanged, nil
+	}
+
+	// update against the observed version of the resource
+	obj.SetResourceVersion(resource.GetResourceVersion())
+	resource, err = options.Updater(obj, subresources...)
+	return resource, ApplyActionUpdated, err
+}
+
+// NewResourceActionApplier returns a new instance of ResourceActionApplier
+// that is capable of applying any resource into kubernetes cluster
+//
+// NOTE:
+//  Every apply is recorded in the resource apply metrics
+func NewResourceActionApplier(gvr schema.GroupVersionResource, namespace string) ResourceActionApplier {
+	return NewResourceActionApplierWithContext(context.Background(), gvr, namespace)
+}
+
+// NewResourceActionApplierWithContext returns a new instance of
+// ResourceActionApplier whose API calls as well as retries honour the given
+// context
+func NewResourceActionApplierWithContext(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ResourceActionApplier {
 	options := ResourceApplyOptions{
-		Getter:  NewResourceGetter(gvr, namespace),
-		Creator: NewResourceCreator(gvr, namespace),
-		Updater: NewResourceUpdater(gvr, namespace),
+		Context:     ctx,
+		Getter:      NewResourceGetterWithContext(ctx, gvr, namespace),
+		Creator:     NewResourceCreatorWithContext(ctx, gvr, namespace),
+		Updater:     NewResourceUpdaterWithContext(ctx, gvr, namespace),
+		RetryPolicy: DefaultRetryPolicy(),
+	}
+
+	return instrumentResourceActionApplier(gvr, newResourceActionApplier(options))
+}
+
+// NewResourceActionApplierWithOptions returns a new instance of
+// ResourceActionApplier that makes use of the provided options
+func NewResourceActionApplierWithOptions(options ResourceApplyOptions) ResourceActionApplier {
+	return newResourceActionApplier(options)
+}
+
+// ResourceApplier abstracts applying an unstructured instance that may or may
+// not be available in kubernetes cluster
+type ResourceApplier func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error)
+
+// newResourceApplier returns a new instance of ResourceApplier that makes use
+// of the given ResourceActionApplier to apply an unstructured instance that
+// may or may not be available in kubernetes cluster
+func newResourceApplier(apply ResourceActionApplier) ResourceApplier {
+	return func(obj *unstructured.Unstructured, subresources ...string) (*unstructured.Unstructured, error) {
+		resource, _, err := apply(obj, subresources...)
+		return resource, err
 	}
+}
 
-	return newResourceApplier(options)
+// NewResourceApplier returns a new instance of ResourceApplier that is capable
+// of applying any resource into kubernetes cluster
+func NewResourceApplier(gvr schema.GroupVersionResource, namespace string) ResourceApplier {
+	return newResourceApplier(NewResourceActionApplier(gvr, namespace))
 }
@@ -18,6 +18,8 @@ package v1alpha1
 
 import (
 	"fmt"
+	"sort"
+
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -27,6 +29,8 @@ type Artifact struct {
 	GroupVersionResource schema.GroupVersionResource
 	// Doc represents the JSON compatible artifact
 	Doc string
+	// Engine is the storage engine this artifact belongs to
+	Engine CASEngine
 }
 
 // ArtifactMiddleware abstracts updating a given artifact
@@ -41,12 +45,26 @@ type ArtifactList struct {
 // provided version
 type VersionArtifactLister func(version string) (ArtifactList, error)
 
+// artifactListersByVersion maps a supported version to the function that
+// lists the artifacts of that version
+var artifactListersByVersion = map[string]func() ArtifactList{
+	"0.7.0": RegisteredArtifactsFor070,
+}
+
+// ListVersions returns the versions whose artifacts can be installed
+func ListVersions() (versions []string) {
+	for version := range artifactListersByVersion {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return
+}
+
 // ListArtifactsByVersion returns artifacts based on the provided version
 func ListArtifactsByVersion(version string) (ArtifactList, error) {
-	switch version {
-	case "0.7.0":
-		return RegisteredArtifactsFor070(), nil
-	default:
+	list, found := artifactListersByVersion[version]
+	if !found {
 		return ArtifactList{}, fmt.Errorf("invalid version '%s': failed to list artifacts by version", version)
 	}
+	return list(), nil
 }
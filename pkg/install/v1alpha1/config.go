@@ -18,6 +18,9 @@ package v1alpha1
 
 type InstallConfig struct {
 	Spec InstallConfigSpec `json:"spec"`
+	// ResourceVersion is the resource version of the config map this install
+	// config was fetched from; empty if not fetched from a config map
+	ResourceVersion string `json:"-"`
 }
 
 type InstallConfigSpec struct {
@@ -33,6 +36,29 @@ type InstallConfigSpec struct {
 	// NOTE:
 	//  Only specific resources can be un-installed
 	Uninstall []Uninstall `json:"uninstall"`
+	// Wait determines how the readiness of the installed artifacts of a kind
+	// is waited upon; this overrides the readiness understood by installer
+	//
+	// NOTE:
+	//  Installer waits for readiness if this is specified
+	Wait []WaitOptions `json:"wait,omitempty"`
+}
+
+// WaitOptions determines how the readiness of the installed artifacts of a
+// kind is waited upon
+type WaitOptions struct {
+	// Kind of the artifacts to be waited upon e.g. CStorPool
+	Kind string `json:"kind"`
+	// JSONPath of the field that reflects the readiness of the artifact e.g.
+	// {.status.phase}
+	JSONPath string `json:"jsonPath"`
+	// Value of the field when the artifact is ready e.g. Online
+	Value string `json:"value"`
+	// Timeout is the maximum time to wait for the artifact to be ready e.g.
+	// 5m; defaults to the readiness timeout of installer
+	Timeout string `json:"timeout,omitempty"`
+	// Interval is the time between two readiness checks e.g. 5s
+	Interval string `json:"interval,omitempty"`
 }
 
 // Install provides metadata information about one or more artifacts that
@@ -42,6 +68,12 @@ type Install struct {
 	Version string `json:"version"`
 	// SetOptions will override the defaults of this install version
 	SetOptions SetOptions `json:"set"`
+	// Include selects the artifacts of this install version to be
+	// installed; all the artifacts are selected if not specified
+	Include []ArtifactSelector `json:"include,omitempty"`
+	// Exclude selects the artifacts of this install version that should not
+	// be installed
+	Exclude []ArtifactSelector `json:"exclude,omitempty"`
 }
 
 // SetOptions will override this install version resource(s) with these values
@@ -52,8 +84,38 @@ type SetOptions struct {
 	Labels map[string]string `json:"labels"`
 	// Annotations to be set against the artifacts before install
 	Annotations map[string]string `json:"annotations"`
+	// MergeStrategy determines how the labels & annotations are set against
+	// the labels & annotations the artifacts already have; defaults to merge
+	MergeStrategy MergeStrategy `json:"mergeStrategy,omitempty"`
+	// RemoveLabels are the label keys to be removed from the artifacts
+	// before install
+	RemoveLabels []string `json:"removeLabels,omitempty"`
+	// RemoveAnnotations are the annotation keys to be removed from the
+	// artifacts before install
+	RemoveAnnotations []string `json:"removeAnnotations,omitempty"`
+	// DefaultConfig is set against the default config of the cas templates
+	// it is keyed by i.e. a template name or an engine
+	DefaultConfig map[string]DefaultConfigOptions `json:"defaultConfig,omitempty"`
+	// Patches are applied to the artifacts they target after the other set
+	// options
+	Patches []Patch `json:"patches,omitempty"`
 }
 
+// MergeStrategy is a typed string to represent how a set of key value pairs
+// is set against the existing key value pairs
+type MergeStrategy string
+
+const (
+	// MergeStrategyMerge retains the existing pairs unless overridden by the
+	// pairs being set
+	MergeStrategyMerge MergeStrategy = "merge"
+	// MergeStrategyReplace discards the existing pairs
+	MergeStrategyReplace MergeStrategy = "replace"
+	// MergeStrategyMergeWithoutOverride retains the existing pairs even if
+	// the pairs being set have the same keys
+	MergeStrategyMergeWithoutOverride MergeStrategy = "merge-without-override"
+)
+
 // Uninstall provides metadata information about one or more artifacts that
 // need to be un-installed
 type Uninstall struct {
@@ -17,8 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"io/ioutil"
 	"strings"
 
 	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
@@ -58,6 +60,69 @@ func WithConfigMapConfigGetter(getter k8s.ConfigMapGetter) ConfigGetterFunc {
 			return nil, fmt.Errorf("missing install config specs: failed to get install config from config map '%s'", name)
 		}
 
-		return UnmarshallConfig(installSpecs)
+		config, err = UnmarshallConfig(installSpecs)
+		if err != nil {
+			return nil, err
+		}
+		config.ResourceVersion = cm.ResourceVersion
+		return config, nil
+	}
+}
+
+// ContextConfigGetterFunc abstracts fetching an instance of install config
+// within the given context
+type ContextConfigGetterFunc func(ctx context.Context, name string) (config *InstallConfig, err error)
+
+// WithContextConfigMapConfigGetter returns an instance of
+// ContextConfigGetterFunc that is capable of fetching install config from a
+// kubernetes ConfigMap of the given namespace
+//
+// NOTE:
+//  The ConfigMap is fetched within the context of the caller
+func WithContextConfigMapConfigGetter(namespace string) ContextConfigGetterFunc {
+	return func(ctx context.Context, name string) (*InstallConfig, error) {
+		return WithConfigMapConfigGetter(k8s.NewConfigMapGetterWithContext(ctx, namespace))(name)
+	}
+}
+
+// IgnoreContextConfigGetter returns an instance of ContextConfigGetterFunc
+// that fetches install config via the given config getter irrespective of
+// the context of the caller
+//
+// NOTE:
+//  This suits the config getters that do not call kubernetes cluster e.g.
+// the one that reads the install config from a file. Nil is returned if the
+// given config getter is nil.
+func IgnoreContextConfigGetter(getter ConfigGetterFunc) ContextConfigGetterFunc {
+	if getter == nil {
+		return nil
+	}
+	return func(ctx context.Context, name string) (*InstallConfig, error) {
+		return getter(name)
+	}
+}
+
+// WithFileConfigGetter returns an instance of ConfigGetterFunc that is
+// capable of fetching install config from a local file
+//
+// NOTE:
+//  The provided name is ignored since the install config is read from the
+// given file path. This does not need a kubernetes cluster.
+func WithFileConfigGetter(path string) ConfigGetterFunc {
+	return func(name string) (config *InstallConfig, err error) {
+		if len(strings.TrimSpace(path)) == 0 {
+			return nil, fmt.Errorf("missing file path: failed to get install config from file")
+		}
+
+		installSpecs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get install config from file '%s'", path)
+		}
+
+		if len(strings.TrimSpace(string(installSpecs))) == 0 {
+			return nil, fmt.Errorf("missing install config specs: failed to get install config from file '%s'", path)
+		}
+
+		return UnmarshallConfig(string(installSpecs))
 	}
 }
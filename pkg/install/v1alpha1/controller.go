@@ -0,0 +1,460 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// defaultResyncInterval is the interval at which install is re-run if not
+// specified
+const defaultResyncInterval = 10 * time.Minute
+
+// resyncInterval returns the interval at which install controller re-runs
+// install even if the install config has not changed
+func resyncInterval() time.Duration {
+	interval, err := time.ParseDuration(env.Get(string(EnvKeyForInstallResyncInterval)))
+	if err != nil || interval <= 0 {
+		return defaultResyncInterval
+	}
+	return interval
+}
+
+// driftMode returns what install controller does on detecting a drift at
+// every resync
+//
+// NOTE:
+//  Drifted resources are healed if the mode is not specified or is invalid.
+// In report mode, drift is healed by re-running install.
+func driftMode() DriftMode {
+	value := env.Get(string(EnvKeyForInstallDriftMode))
+	if len(strings.TrimSpace(value)) == 0 {
+		return DriftModeHeal
+	}
+
+	mode, err := DriftModeFor(value)
+	if err != nil {
+		glog.Warningf("%v: using drift mode '%s'", err, DriftModeHeal)
+		return DriftModeHeal
+	}
+	return mode
+}
+
+// resyncKey is the queue key of an install config that was resynced
+//
+// NOTE:
+//  A resynced install config is checked for drift & is installed again only
+// if the drift was not healed
+type resyncKey string
+
+// Controller abstracts running install continuously
+type Controller interface {
+	// Run blocks till the given stop channel is closed
+	Run(stopCh <-chan struct{}) error
+}
+
+// InstallerBuilder abstracts building an installer that makes use of the
+// given config getter to fetch the install config
+type InstallerBuilder func(configGetter ConfigGetterFunc) Installer
+
+// installController re-runs install whenever its install config changes &
+// detects the drift of the installed resources at regular intervals
+//
+// NOTE:
+//  This is an implementation of Controller
+type installController struct {
+	namespace        string
+	configName       string
+	resync           time.Duration
+	driftMode        DriftMode
+	clientsetGetter  k8s.ClientsetGetter
+	installerBuilder InstallerBuilder
+	leaderElection   bool
+	status           *configMapInstallStatus
+	installer        Installer
+	config           *corev1.ConfigMap
+	queue            workqueue.RateLimitingInterface
+	store            cache.Store
+}
+
+// InstallController returns a new instance of install controller that
+// watches the install config referred to by the install environment keys
+func InstallController() *installController {
+	namespace := env.Get(string(EnvKeyForInstallConfigNamespace))
+	configName := env.Get(string(EnvKeyForInstallConfigName))
+
+	// install status of the controller is shared with its installer
+	var c *installController
+	c = NewInstallController(namespace, configName, func(configGetter ConfigGetterFunc) Installer {
+		installer := NewSimpleInstaller(configGetter)
+		installer.history = NewConfigMapInstallHistory(namespace, configName)
+		installer.status = c.status
+		return installer
+	})
+	return c
+}
+
+// NewInstallController returns a new instance of install controller that
+// watches the given install config & makes use of the provided builder to
+// run install
+func NewInstallController(namespace, configName string, builder InstallerBuilder) *installController {
+	return &installController{
+		namespace:        namespace,
+		configName:       configName,
+		resync:           resyncInterval(),
+		driftMode:        driftMode(),
+		clientsetGetter:  k8s.NewClientsetGetter(),
+		installerBuilder: builder,
+		leaderElection:   isLeaderElectionEnabled(),
+		status:           newConfigMapInstallStatus(namespace, configName),
+	}
+}
+
+// Run watches the install config and re-runs install till the given stop
+// channel is closed
+//
+// NOTE:
+//  If leader election is enabled install is re-run only while this replica
+// is the elected leader. This replica contends for the leadership again
+// once the leadership is lost.
+//
+// NOTE:
+//  This is an implementation of Controller interface
+func (c *installController) Run(stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+
+	if len(strings.TrimSpace(c.configName)) == 0 {
+		return fmt.Errorf("missing install config name: failed to run install controller")
+	}
+
+	if c.installerBuilder == nil {
+		return fmt.Errorf("nil installer builder: failed to run install controller")
+	}
+
+	cs, err := c.clientsetGetter.Get()
+	if err != nil {
+		return errors.Wrap(err, "failed to run install controller")
+	}
+
+	// metrics are served only if an address is specified
+	if address := metricsAddress(); len(address) != 0 {
+		go func() {
+			err := ServeMetrics(address, stopCh)
+			if err != nil {
+				utilruntime.HandleError(errors.Wrap(err, "install controller failed to serve metrics"))
+			}
+		}()
+	}
+
+	if !c.leaderElection {
+		return c.run(cs, stopCh)
+	}
+
+	identity, err := leaderIdentity()
+	if err != nil {
+		return errors.Wrap(err, "failed to run install controller")
+	}
+
+	elector := NewConfigMapLeaderElector(c.namespace, c.configName, identity, cs.CoreV1())
+	wait.Until(func() {
+		err := elector.Run(stopCh, func(leadCh <-chan struct{}) {
+			err := c.run(cs, leadCh)
+			if err != nil {
+				utilruntime.HandleError(err)
+			}
+		})
+		if err != nil {
+			utilruntime.HandleError(errors.Wrap(err, "install controller lost leadership"))
+		}
+	}, time.Second, stopCh)
+
+	return nil
+}
+
+// run watches the install config and re-runs install till the given stop
+// channel is closed
+//
+// NOTE:
+//  The install that is in progress is interrupted once the stop channel is
+// closed & is waited upon before this returns
+//
+// NOTE:
+//  A new queue is used every time this is run since the queue is shut down
+// on stop e.g. when the leadership is lost
+func (c *installController) run(cs *kubernetes.Clientset, stopCh <-chan struct{}) error {
+	c.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "install")
+	defer c.queue.ShutDown()
+
+	// only the install config is watched
+	lw := cache.NewListWatchFromClient(cs.CoreV1().RESTClient(), "configmaps", c.namespace,
+		fields.OneTermEqualSelector("metadata.name", c.configName))
+
+	store, informer := cache.NewInformer(lw, &corev1.ConfigMap{}, c.resync, cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: c.enqueueOnChange,
+	})
+	c.store = store
+
+	go informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		return fmt.Errorf("failed to sync install config '%s': failed to run install controller", c.configName)
+	}
+
+	glog.Infof("install controller started for install config '%s/%s'", c.namespace, c.configName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a single worker is sufficient since there is only one install config
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		wait.Until(func() { c.runWorker(ctx) }, time.Second, stopCh)
+	}()
+
+	<-stopCh
+	cancel()
+	c.queue.ShutDown()
+	wg.Wait()
+
+	glog.Infof("install controller stopped for install config '%s/%s'", c.namespace, c.configName)
+	return nil
+}
+
+// enqueue adds the given install config to the queue
+func (c *installController) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(errors.Wrap(err, "install controller failed to enqueue install config"))
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueOnChange adds the given install config to the queue either on a
+// periodic resync or if its specs have changed
+//
+// NOTE:
+//  Changes made only to the metadata e.g. status annotations set by this
+// controller are ignored
+func (c *installController) enqueueOnChange(oldObj, newObj interface{}) {
+	oldCM, oldOK := oldObj.(*corev1.ConfigMap)
+	newCM, newOK := newObj.(*corev1.ConfigMap)
+	if !oldOK || !newOK {
+		return
+	}
+
+	// resync results in an update having the same resource version
+	if oldCM.ResourceVersion == newCM.ResourceVersion {
+		c.enqueueResync(newObj)
+	} else if !reflect.DeepEqual(oldCM.Data, newCM.Data) {
+		c.enqueue(newObj)
+	}
+}
+
+// enqueueResync adds the given install config to the queue to be checked for
+// drift
+func (c *installController) enqueueResync(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(errors.Wrap(err, "install controller failed to enqueue install config"))
+		return
+	}
+	c.queue.Add(resyncKey(key))
+}
+
+// runWorker processes the queue till it is shut down
+func (c *installController) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+// processNextItem reconciles the next install config in the queue
+//
+// NOTE:
+//  A failed reconcile is re-queued with rate limiting
+func (c *installController) processNextItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	// pending install configs are not reconciled once stopped
+	if c.queue.ShuttingDown() {
+		return false
+	}
+
+	var err error
+	switch k := key.(type) {
+	case resyncKey:
+		err = c.detectDrift(ctx, string(k))
+	case string:
+		err = c.reconcile(ctx, k)
+	}
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+
+	utilruntime.HandleError(errors.Wrapf(err, "install controller will retry install config '%s'", key))
+	c.queue.AddRateLimited(key)
+	return true
+}
+
+// reconcile runs install against the given install config & sets the install
+// state as its annotations
+//
+// NOTE:
+//  Install is interrupted once the given context is done provided the
+// installer supports it
+func (c *installController) reconcile(ctx context.Context, key string) error {
+	obj, exists, err := c.store.GetByKey(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconcile install config '%s'", key)
+	}
+
+	if !exists {
+		glog.Infof("install config '%s' no longer exists: skipping reconcile", key)
+		return nil
+	}
+
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return fmt.Errorf("invalid install config '%s': failed to reconcile: expected config map got '%T'", key, obj)
+	}
+
+	// install config is fetched from the informer's cache
+	c.config = cm
+	report := installWithContext(ctx, c.getInstaller())
+	glog.Infof("install config '%s' reconciled: %d resources: %d created, %d updated, %d unchanged, %d failed",
+		key, report.Summary.Total, report.Summary.Created, report.Summary.Updated, report.Summary.Unchanged, report.Summary.Failed)
+
+	// the specs that were reconciled are recorded to help in debugging
+	_, err = c.status.annotate(ctx, map[string]string{
+		string(InstallObservedDigestAnnotationKey): fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(cm.Data["install"]))),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to reconcile install config '%s'", key)
+	}
+
+	if !report.IsSuccess() {
+		return fmt.Errorf("install failed: failed to reconcile install config '%s': %s", key, strings.Join(installReportErrors(report), "; "))
+	}
+
+	return nil
+}
+
+// detectDrift compares the resources specified in the given install config
+// against the live resources & heals the drifted ones if the drift mode is
+// heal
+//
+// NOTE:
+//  Install is re-run if any of the resources is still drifted e.g. in report
+// mode. Install is run instead if the installer can not detect drift.
+func (c *installController) detectDrift(ctx context.Context, key string) error {
+	obj, exists, err := c.store.GetByKey(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to detect drift of install config '%s'", key)
+	}
+
+	if !exists {
+		glog.Infof("install config '%s' no longer exists: skipping drift detection", key)
+		return nil
+	}
+
+	cm, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return fmt.Errorf("invalid install config '%s': failed to detect drift: expected config map got '%T'", key, obj)
+	}
+
+	detector, ok := c.getInstaller().(DriftDetector)
+	if !ok {
+		return c.reconcile(ctx, key)
+	}
+
+	// install config is fetched from the informer's cache
+	c.config = cm
+	report, errs := detector.DetectDrift(ctx, c.driftMode)
+	glog.Infof("install config '%s' checked for drift in '%s' mode: %d in-sync, %d drifted, %d missing, %d unknown",
+		key, report.Mode, report.Count(DriftStatusInSync), report.Count(DriftStatusDrifted), report.Count(DriftStatusMissing), report.Count(DriftStatusUnknown))
+
+	if len(errs) != 0 {
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("drift detection failed: failed to detect drift of install config '%s': %s", key, strings.Join(msgs, "; "))
+	}
+
+	if report.IsDrifted() {
+		glog.Infof("install config '%s' has drifted: re-running install", key)
+		return c.reconcile(ctx, key)
+	}
+
+	return nil
+}
+
+// getInstaller returns the installer used to reconcile the install config
+//
+// NOTE:
+//  The installer is built once & is re-used across reconciles. It fetches
+// the install config that is being reconciled.
+func (c *installController) getInstaller() Installer {
+	if c.installer != nil {
+		return c.installer
+	}
+
+	getter := k8s.ConfigMapGetterFunc(func(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+		if c.config == nil {
+			return nil, fmt.Errorf("install config '%s' is not yet reconciled: failed to get install config", name)
+		}
+		return c.config.DeepCopy(), nil
+	})
+
+	c.installer = c.installerBuilder(WithConfigMapConfigGetter(getter))
+	return c.installer
+}
+
+// installWithContext runs install via the given installer that stops once
+// the given context is done if the installer is a ContextInstaller
+func installWithContext(ctx context.Context, installer Installer) InstallReport {
+	if ci, ok := installer.(ContextInstaller); ok {
+		return ci.InstallContext(ctx)
+	}
+	return installer.Install()
+}
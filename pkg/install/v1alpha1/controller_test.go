@@ -0,0 +1,201 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// fakeInstaller is an installer that records the install configs it got
+type fakeInstaller struct {
+	configGetter ConfigGetterFunc
+	versions     []string
+}
+
+func (f *fakeInstaller) Install() InstallReport {
+	config, err := f.configGetter("install-config")
+	if err != nil {
+		return newInstallReport(nil, []error{err})
+	}
+	f.versions = append(f.versions, config.Spec.Install[0].Version)
+	return InstallReport{}
+}
+
+// fakeDriftInstaller is an installer that can detect drift
+type fakeDriftInstaller struct {
+	fakeInstaller
+	modes   []DriftMode
+	drifted bool
+}
+
+func (f *fakeDriftInstaller) DetectDrift(ctx context.Context, mode DriftMode) (DriftReport, []error) {
+	f.modes = append(f.modes, mode)
+	report := DriftReport{Mode: mode}
+	if f.drifted {
+		report.Items = []DriftItem{{Kind: "ConfigMap", Name: "one", Status: DriftStatusDrifted}}
+	}
+	return report, nil
+}
+
+func fakeInstallConfigMap(version string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "install-config", Namespace: "openebs", ResourceVersion: "1"},
+		Data:       map[string]string{"install": "spec:\n  install:\n  - version: " + version + "\n"},
+	}
+}
+
+func TestGetInstaller(t *testing.T) {
+	var builds int
+	installer := &fakeInstaller{}
+	c := NewInstallController("openebs", "install-config", func(configGetter ConfigGetterFunc) Installer {
+		builds++
+		installer.configGetter = configGetter
+		return installer
+	})
+
+	report := c.getInstaller().Install()
+	if report.IsSuccess() {
+		t.Fatalf("expected install to fail before the install config is reconciled: got success")
+	}
+
+	for _, version := range []string{"0.7.0", "0.8.0"} {
+		c.config = fakeInstallConfigMap(version)
+		if report := c.getInstaller().Install(); !report.IsSuccess() {
+			t.Fatalf("expected install of '%s' to succeed: got '%v'", version, report.Errors)
+		}
+	}
+
+	if builds != 1 {
+		t.Fatalf("expected installer to be built once: got '%d'", builds)
+	}
+	if len(installer.versions) != 2 || installer.versions[0] != "0.7.0" || installer.versions[1] != "0.8.0" {
+		t.Fatalf("expected installed versions '[0.7.0 0.8.0]': got '%v'", installer.versions)
+	}
+}
+
+func TestDriftMode(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected DriftMode
+	}{
+		"not set defaults to heal": {value: "", expected: DriftModeHeal},
+		"report":                   {value: "report", expected: DriftModeReport},
+		"heal":                     {value: "heal", expected: DriftModeHeal},
+		"invalid defaults to heal": {value: "ignore", expected: DriftModeHeal},
+	}
+
+	defer os.Unsetenv(string(EnvKeyForInstallDriftMode))
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(EnvKeyForInstallDriftMode), mock.value)
+			if got := driftMode(); got != mock.expected {
+				t.Fatalf("expected drift mode '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestEnqueueOnChange(t *testing.T) {
+	changed := fakeInstallConfigMap("0.8.0")
+	changed.ResourceVersion = "2"
+	annotated := fakeInstallConfigMap("0.7.0")
+	annotated.ResourceVersion = "2"
+	annotated.Annotations = map[string]string{"openebs.io/install-state": "Succeeded"}
+
+	tests := map[string]struct {
+		newObj   *corev1.ConfigMap
+		expected interface{}
+	}{
+		"resync is checked for drift": {newObj: fakeInstallConfigMap("0.7.0"), expected: resyncKey("openebs/install-config")},
+		"changed specs are installed": {newObj: changed, expected: "openebs/install-config"},
+		"changed metadata is ignored": {newObj: annotated},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &installController{queue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())}
+			defer c.queue.ShutDown()
+
+			c.enqueueOnChange(fakeInstallConfigMap("0.7.0"), mock.newObj)
+			if mock.expected == nil {
+				if c.queue.Len() != 0 {
+					t.Fatalf("expected nothing to be queued: got '%d' items", c.queue.Len())
+				}
+				return
+			}
+
+			if c.queue.Len() != 1 {
+				t.Fatalf("expected one queued item: got '%d'", c.queue.Len())
+			}
+			if got, _ := c.queue.Get(); got != mock.expected {
+				t.Fatalf("expected queued item '%#v': got '%#v'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessNextItemOnResync(t *testing.T) {
+	tests := map[string]struct {
+		drifted        bool
+		expectInstalls int
+	}{
+		"in sync is not installed again": {drifted: false, expectInstalls: 0},
+		"drift is healed by install":     {drifted: true, expectInstalls: 1},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			installer := &fakeDriftInstaller{drifted: mock.drifted}
+			c := NewInstallController("openebs", "install-config", func(configGetter ConfigGetterFunc) Installer {
+				installer.configGetter = configGetter
+				return installer
+			})
+			c.driftMode = DriftModeReport
+			c.status = fakeInstallStatus(record.NewFakeRecorder(10))
+			c.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			defer c.queue.ShutDown()
+			c.store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+			c.store.Add(fakeInstallConfigMap("0.7.0"))
+
+			c.queue.Add(resyncKey("openebs/install-config"))
+			if !c.processNextItem(context.Background()) {
+				t.Fatalf("expected queue to be processed: got shut down")
+			}
+
+			if len(installer.modes) != 1 || installer.modes[0] != DriftModeReport {
+				t.Fatalf("expected drift to be detected once in '%s' mode: got '%v'", DriftModeReport, installer.modes)
+			}
+			if len(installer.versions) != mock.expectInstalls {
+				t.Fatalf("expected '%d' installs on resync: got '%v'", mock.expectInstalls, installer.versions)
+			}
+			if c.config == nil || c.config.Name != "install-config" {
+				t.Fatalf("expected install config to be the one being checked for drift: got '%v'", c.config)
+			}
+			if c.queue.Len() != 0 {
+				t.Fatalf("expected resync not to be retried: got '%d' queued items", c.queue.Len())
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ package v1alpha1
 func CstorVolumeArtifactsFor070() (list ArtifactList) {
 	list.Items = append(list.Items, cstorVolumeCASTemplatesFor070()...)
 	list.Items = append(list.Items, cstorVolumeRunTasksFor070()...)
+	list.Items = WithCASEngineListUpdater(CASEngineCStor, list.Items)
 
 	return
 }
@@ -140,6 +141,7 @@ func cstorVolumeRunTasksFor070() (runtasks []*Artifact) {
 		cstorVolumeCreatePutTargetDeploymentDefault070(),
 		cstorVolumeCreatePutCstorVolumeReplicaCRDefault070(),
 		cstorVolumeCreateOutputDefault070(),
+		cstorVolumeListListTargetServiceDefault070(),
 		cstorVolumeListListTargetPodDefault070(),
 		cstorVolumeListListCstorVolumeReplicaCRDefault070(),
 		cstorVolumeListOutputDefault070(),
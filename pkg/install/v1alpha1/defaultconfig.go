@@ -0,0 +1,315 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// DefaultConfigKey is a typed string to represent the name of an entry of
+// a cas template's default config
+type DefaultConfigKey string
+
+const (
+	// DefaultConfigKeyReplicaCount is the number of replicas of a volume
+	DefaultConfigKeyReplicaCount DefaultConfigKey = "ReplicaCount"
+	// DefaultConfigKeyControllerImage is the image of a volume controller
+	DefaultConfigKeyControllerImage DefaultConfigKey = "ControllerImage"
+	// DefaultConfigKeyReplicaImage is the image of a volume replica
+	DefaultConfigKeyReplicaImage DefaultConfigKey = "ReplicaImage"
+	// DefaultConfigKeyVolumeMonitorImage is the image of a volume monitor
+	DefaultConfigKeyVolumeMonitorImage DefaultConfigKey = "VolumeMonitorImage"
+	// DefaultConfigKeyStoragePool is the storage pool of a volume
+	DefaultConfigKeyStoragePool DefaultConfigKey = "StoragePool"
+	// DefaultConfigKeyEvictionTolerations are the tolerations of a volume's
+	// pods
+	DefaultConfigKeyEvictionTolerations DefaultConfigKey = "EvictionTolerations"
+)
+
+// DefaultConfigEntry is an entry of a cas template's default config
+type DefaultConfigEntry struct {
+	// Name of the entry e.g. ReplicaCount
+	Name DefaultConfigKey `json:"name"`
+	// Value to be set against the entry
+	Value string `json:"value,omitempty"`
+	// Enabled to be set against the entry e.g. "true"
+	Enabled string `json:"enabled,omitempty"`
+}
+
+// DefaultConfigOptions has the entries to be set against or removed from
+// the default config of cas templates
+type DefaultConfigOptions struct {
+	// Set upserts these entries by their names
+	Set []DefaultConfigEntry `json:"set,omitempty"`
+	// Remove removes the entries having these names
+	Remove []DefaultConfigKey `json:"remove,omitempty"`
+}
+
+// validate returns error if these options can not be set
+func (o DefaultConfigOptions) validate() error {
+	if len(o.Set) == 0 && len(o.Remove) == 0 {
+		return fmt.Errorf("no entries to set or remove")
+	}
+
+	names := map[DefaultConfigKey]bool{}
+	for _, entry := range o.Set {
+		if len(entry.Name) == 0 {
+			return fmt.Errorf("entry without name")
+		}
+		if len(entry.Value) == 0 && len(entry.Enabled) == 0 {
+			return fmt.Errorf("entry '%s' without value or enabled", entry.Name)
+		}
+		if names[entry.Name] {
+			return fmt.Errorf("duplicate entry '%s'", entry.Name)
+		}
+		names[entry.Name] = true
+	}
+	for _, name := range o.Remove {
+		if names[name] {
+			return fmt.Errorf("entry '%s' is both set & removed", name)
+		}
+	}
+	return nil
+}
+
+// names returns the names of all the entries of these options
+func (o DefaultConfigOptions) names() (names []DefaultConfigKey) {
+	for _, entry := range o.Set {
+		names = append(names, entry.Name)
+	}
+	return append(names, o.Remove...)
+}
+
+// ArtifactListUpdater abstracts updating the artifacts of a list
+type ArtifactListUpdater func(list ArtifactList) (updated ArtifactList, errs []error)
+
+// defaultConfigTemplate is a cas template artifact along with its parsed
+// document
+type defaultConfigTemplate struct {
+	meta artifactMeta
+	doc  map[string]interface{}
+}
+
+// entries returns the default config entries declared by this template
+func (t defaultConfigTemplate) entries() []interface{} {
+	spec, _ := t.doc["spec"].(map[string]interface{})
+	entries, _ := spec["defaultConfig"].([]interface{})
+	return entries
+}
+
+// declares returns true if this template declares an entry with the given
+// name
+func (t defaultConfigTemplate) declares(name DefaultConfigKey) bool {
+	return indexOfDefaultConfigEntry(t.entries(), name) != -1
+}
+
+// matches returns true if this template is targeted by the given key i.e.
+// the template's name or engine
+//
+// NOTE:
+//  A key that is an engine targets the engine's templates that declare a
+// default config
+func (t defaultConfigTemplate) matches(key string) bool {
+	if key == t.meta.Name {
+		return true
+	}
+	return strings.EqualFold(key, string(t.meta.Engine)) && len(t.entries()) != 0
+}
+
+// setDefaultConfig upserts & removes the entries of this template as per the
+// given options
+func (t defaultConfigTemplate) setDefaultConfig(options DefaultConfigOptions) {
+	entries := t.entries()
+	for _, entry := range options.Set {
+		idx := indexOfDefaultConfigEntry(entries, entry.Name)
+		if idx == -1 {
+			entries = append(entries, map[string]interface{}{"name": string(entry.Name)})
+			idx = len(entries) - 1
+		}
+		existing := entries[idx].(map[string]interface{})
+		if len(entry.Value) != 0 {
+			existing["value"] = entry.Value
+		}
+		if len(entry.Enabled) != 0 {
+			existing["enabled"] = entry.Enabled
+		}
+	}
+	for _, name := range options.Remove {
+		if idx := indexOfDefaultConfigEntry(entries, name); idx != -1 {
+			entries = append(entries[:idx], entries[idx+1:]...)
+		}
+	}
+
+	spec, _ := t.doc["spec"].(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+		t.doc["spec"] = spec
+	}
+	spec["defaultConfig"] = entries
+}
+
+// indexOfDefaultConfigEntry returns the index of the entry with the given
+// name; -1 if not found
+func indexOfDefaultConfigEntry(entries []interface{}, name DefaultConfigKey) int {
+	for idx, entry := range entries {
+		if e, ok := entry.(map[string]interface{}); ok && e["name"] == string(name) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// sortedDefaultConfigKeys returns the keys of the given default config
+// options such that the engine keys are ordered before the template keys
+//
+// NOTE:
+//  This lets the options of a template override those of its engine
+func sortedDefaultConfigKeys(options map[string]DefaultConfigOptions) (keys []string) {
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ei, ej := isValidCASEngine(CASEngine(strings.ToLower(keys[i]))), isValidCASEngine(CASEngine(strings.ToLower(keys[j])))
+		if ei != ej {
+			return ei
+		}
+		return keys[i] < keys[j]
+	})
+	return
+}
+
+// UpdateDefaultConfigArtifactListFor returns the artifact list updater that
+// sets the default config options of the given install against the cas
+// templates of the list
+//
+// NOTE:
+//  Options are keyed by a template name or an engine. An entry is rejected
+// if none of the targeted templates declare it.
+//
+// NOTE:
+//  Nothing is updated if any of the options is invalid
+func UpdateDefaultConfigArtifactListFor(install Install) ArtifactListUpdater {
+	return func(list ArtifactList) (updated ArtifactList, errs []error) {
+		options := install.SetOptions.DefaultConfig
+		if len(options) == 0 {
+			return list, nil
+		}
+
+		templates := map[*Artifact]defaultConfigTemplate{}
+		for _, artifact := range list.Items {
+			meta, err := artifactMetaFrom(artifact)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to set default config for version '%s'", install.Version))
+				continue
+			}
+			if meta.Kind != "CASTemplate" {
+				continue
+			}
+
+			doc := map[string]interface{}{}
+			err = yaml.Unmarshal([]byte(artifact.Doc), &doc)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to set default config of '%s' for version '%s'", meta.Name, install.Version))
+				continue
+			}
+			templates[artifact] = defaultConfigTemplate{meta: meta, doc: doc}
+		}
+		if len(errs) != 0 {
+			return
+		}
+
+		keys := sortedDefaultConfigKeys(options)
+		for _, key := range keys {
+			if err := validateDefaultConfig(key, options[key], templates); err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to set default config of '%s' for version '%s'", key, install.Version))
+			}
+		}
+		if len(errs) != 0 {
+			return
+		}
+
+		for _, artifact := range list.Items {
+			template, found := templates[artifact]
+			if !found {
+				updated.Items = append(updated.Items, artifact)
+				continue
+			}
+
+			var matched bool
+			for _, key := range keys {
+				if template.matches(key) {
+					matched = true
+					template.setDefaultConfig(options[key])
+				}
+			}
+			if !matched {
+				updated.Items = append(updated.Items, artifact)
+				continue
+			}
+
+			doc, err := yaml.Marshal(template.doc)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to set default config of '%s' for version '%s'", template.meta.Name, install.Version))
+				continue
+			}
+			updated.Items = append(updated.Items, &Artifact{
+				GroupVersionResource: artifact.GroupVersionResource,
+				Doc:                  string(doc),
+				Engine:               artifact.Engine,
+			})
+		}
+
+		return
+	}
+}
+
+// validateDefaultConfig returns error if the given options keyed by a
+// template name or an engine can not be set against the given templates
+func validateDefaultConfig(key string, options DefaultConfigOptions, templates map[*Artifact]defaultConfigTemplate) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	var targets []defaultConfigTemplate
+	for _, template := range templates {
+		if template.matches(key) {
+			targets = append(targets, template)
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("unknown template or engine '%s'", key)
+	}
+
+	for _, name := range options.names() {
+		var declared bool
+		for _, template := range targets {
+			if template.declares(name) {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			return fmt.Errorf("unknown entry '%s': not declared by the default config of '%s'", name, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+// defaultConfigOf returns the default config entries of the cas template
+// with the given name as name value pairs
+func defaultConfigOf(t *testing.T, list ArtifactList, name string) map[string]string {
+	for _, artifact := range list.Items {
+		doc := struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				DefaultConfig []DefaultConfigEntry `json:"defaultConfig"`
+			} `json:"spec"`
+		}{}
+		if err := yaml.Unmarshal([]byte(artifact.Doc), &doc); err != nil {
+			t.Fatalf("expected no error: got '%v'", err)
+		}
+		if doc.Metadata.Name != name {
+			continue
+		}
+
+		entries := map[string]string{}
+		for _, entry := range doc.Spec.DefaultConfig {
+			entries[string(entry.Name)] = entry.Value + entry.Enabled
+		}
+		return entries
+	}
+
+	t.Fatalf("expected cas template '%s': got none", name)
+	return nil
+}
+
+func TestUpdateDefaultConfigArtifactListFor(t *testing.T) {
+	jivaCreate := "jiva-volume-create-default-0.7.0"
+	cstorCreate := "cstor-volume-create-default-0.7.0"
+
+	tests := map[string]struct {
+		options   map[string]DefaultConfigOptions
+		expected  map[string]map[string]string
+		expectErr bool
+	}{
+		"no options": {
+			expected: map[string]map[string]string{jivaCreate: {"ReplicaCount": "3"}},
+		},
+		"set replica count by engine": {
+			options: map[string]DefaultConfigOptions{
+				"jiva": {Set: []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaCount, Value: "1"}}},
+			},
+			expected: map[string]map[string]string{jivaCreate: {"ReplicaCount": "1"}, cstorCreate: {"ReplicaCount": "3"}},
+		},
+		"template overrides its engine": {
+			options: map[string]DefaultConfigOptions{
+				"CSTOR":     {Set: []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaCount, Value: "1"}, {Name: DefaultConfigKeyVolumeMonitorImage, Value: "openebs/m-exporter:0.7.0"}}},
+				cstorCreate: {Set: []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaCount, Value: "2"}}},
+			},
+			expected: map[string]map[string]string{cstorCreate: {"ReplicaCount": "2", "VolumeMonitorImage": "openebs/m-exporter:0.7.0"}},
+		},
+		"remove & set enabled": {
+			options: map[string]DefaultConfigOptions{
+				jivaCreate: {
+					Set:    []DefaultConfigEntry{{Name: "VolumeMonitor", Enabled: "false"}},
+					Remove: []DefaultConfigKey{DefaultConfigKeyStoragePool},
+				},
+			},
+			expected: map[string]map[string]string{jivaCreate: {"VolumeMonitor": "false", "StoragePool": "", "ControllerImage": "openebs/jiva:0.6.0"}},
+		},
+		"entry not declared by the template": {
+			options: map[string]DefaultConfigOptions{
+				"cstor": {Set: []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaImage, Value: "openebs/jiva:0.7.0"}}},
+			},
+			expectErr: true,
+		},
+		"template without default config": {
+			options: map[string]DefaultConfigOptions{
+				"jiva-volume-read-default-0.7.0": {Remove: []DefaultConfigKey{DefaultConfigKeyReplicaCount}},
+			},
+			expectErr: true,
+		},
+		"unknown template or engine": {
+			options: map[string]DefaultConfigOptions{
+				"mayastor": {Set: []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaCount, Value: "1"}}},
+			},
+			expectErr: true,
+		},
+		"entry without value": {
+			options: map[string]DefaultConfigOptions{
+				"jiva": {Set: []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaCount}}},
+			},
+			expectErr: true,
+		},
+		"entry both set & removed": {
+			options: map[string]DefaultConfigOptions{
+				"jiva": {
+					Set:    []DefaultConfigEntry{{Name: DefaultConfigKeyReplicaCount, Value: "1"}},
+					Remove: []DefaultConfigKey{DefaultConfigKeyReplicaCount},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			install := Install{Version: "0.7.0", SetOptions: SetOptions{DefaultConfig: mock.options}}
+			all := RegisteredArtifactsFor070()
+			updated, errs := UpdateDefaultConfigArtifactListFor(install)(all)
+			if mock.expectErr != (len(errs) != 0) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, errs)
+			}
+			if mock.expectErr {
+				return
+			}
+
+			if len(updated.Items) != len(all.Items) {
+				t.Fatalf("expected '%d' artifacts: got '%d'", len(all.Items), len(updated.Items))
+			}
+			for template, expected := range mock.expected {
+				got := defaultConfigOf(t, updated, template)
+				for key, value := range expected {
+					if got[key] != value {
+						t.Fatalf("expected '%s' of '%s' to be '%s': got '%s'", key, template, value, got[key])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// kindRanks determines the order of installation based on kind. Resources of
+// a lower ranked kind are installed before the resources of higher ranked
+// kinds. Kinds that are not listed here have the highest rank.
+var kindRanks = map[string]int{
+	"CustomResourceDefinition": 0,
+	"Namespace":                1,
+}
+
+// defaultKindRank is the rank of the kinds that are not listed in kindRanks
+const defaultKindRank = 2
+
+// kindRank returns the install rank of the given unstructured instance
+func kindRank(unstruct *unstructured.Unstructured) int {
+	rank, found := kindRanks[unstruct.GetKind()]
+	if !found {
+		return defaultKindRank
+	}
+	return rank
+}
+
+// unstructuredKey returns the key that identifies the given unstructured
+// instance unambiguously
+func unstructuredKey(unstruct *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", unstruct.GetKind(), unstruct.GetNamespace(), unstruct.GetName())
+}
+
+// casTemplateReferences returns the names of the run tasks referred to by the
+// given CASTemplate
+func casTemplateReferences(casTemplate *unstructured.Unstructured) (refs []string) {
+	tasks, _, _ := unstructured.NestedStringSlice(casTemplate.Object, "spec", "run", "tasks")
+	refs = append(refs, tasks...)
+
+	output, _, _ := unstructured.NestedString(casTemplate.Object, "spec", "output")
+	if len(strings.TrimSpace(output)) != 0 {
+		refs = append(refs, output)
+	}
+
+	return
+}
+
+// UnstructuredDependencyResolver abstracts ordering of unstructured
+// instances based on their dependencies
+//
+// NOTE:
+//  Each level has the unstructured instances that depend only on the
+// instances of the previous levels
+type UnstructuredDependencyResolver func(list []*unstructured.Unstructured) (levels [][]*unstructured.Unstructured, errs []error)
+
+// ReferenceChecker abstracts checking if a run task referred to by the given
+// CASTemplate is available even though it is not amongst the resources being
+// resolved
+type ReferenceChecker func(casTemplate *unstructured.Unstructured, runTask string) (found bool, err error)
+
+// SkippedReferenceChecker returns a ReferenceChecker that finds the run tasks
+// that were not selected by the install config
+//
+// NOTE:
+//  A run task that is not selected is expected to be managed outside of
+// this install
+func SkippedReferenceChecker(skipped []SkipReportItem) ReferenceChecker {
+	return func(casTemplate *unstructured.Unstructured, runTask string) (bool, error) {
+		for _, item := range skipped {
+			if item.Kind == "RunTask" && item.Name == runTask {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// LiveReferenceChecker returns a ReferenceChecker that finds the run tasks
+// available in kubernetes cluster
+//
+// NOTE:
+//  A run task is looked up as a config map in the task namespace of the
+// CASTemplate & falls back to the namespace of the CASTemplate
+func LiveReferenceChecker(getter func(namespace string) k8s.ConfigMapGetter) ReferenceChecker {
+	return func(casTemplate *unstructured.Unstructured, runTask string) (bool, error) {
+		namespace, _, _ := unstructured.NestedString(casTemplate.Object, "spec", "taskNamespace")
+		if len(strings.TrimSpace(namespace)) == 0 {
+			namespace = casTemplate.GetNamespace()
+		}
+		if len(strings.TrimSpace(namespace)) == 0 {
+			return false, nil
+		}
+
+		_, err := getter(namespace).Get(runTask, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to look up run task '%s/%s'", namespace, runTask)
+		}
+		return true, nil
+	}
+}
+
+// ResolveUnstructuredDependencies orders the given unstructured instances
+// into levels based on their dependencies. A CASTemplate depends on the run
+// tasks it refers to, while CustomResourceDefinitions & Namespaces are
+// depended upon by every other resource.
+//
+// NOTE:
+//  Missing references as well as cyclic dependencies are reported as errors
+//
+// NOTE:
+//  This is an implementation of UnstructuredDependencyResolver
+func ResolveUnstructuredDependencies(list []*unstructured.Unstructured) (levels [][]*unstructured.Unstructured, errs []error) {
+	return ResolveUnstructuredDependenciesWith()(list)
+}
+
+// ResolveUnstructuredDependenciesWith returns an UnstructuredDependencyResolver
+// that considers a run task missing from the given instances as available if
+// any of the given checkers finds it
+//
+// NOTE:
+//  The checkers are tried in the given order
+func ResolveUnstructuredDependenciesWith(checkers ...ReferenceChecker) UnstructuredDependencyResolver {
+	return func(list []*unstructured.Unstructured) ([][]*unstructured.Unstructured, []error) {
+		return resolveUnstructuredDependencies(list, checkers)
+	}
+}
+
+// resolveUnstructuredDependencies orders the given unstructured instances
+// into levels based on their dependencies
+func resolveUnstructuredDependencies(list []*unstructured.Unstructured, checkers []ReferenceChecker) (levels [][]*unstructured.Unstructured, errs []error) {
+	var nodes []*unstructured.Unstructured
+	for _, unstruct := range list {
+		if unstruct != nil {
+			nodes = append(nodes, unstruct)
+		}
+	}
+
+	// index the run task candidates by their names
+	byName := map[string][]int{}
+	for idx, node := range nodes {
+		if node.GetKind() == "CASTemplate" {
+			continue
+		}
+		byName[node.GetName()] = append(byName[node.GetName()], idx)
+	}
+
+	// dependents of a node & count of dependencies of a node
+	dependents := make([][]int, len(nodes))
+	dependencies := make([]int, len(nodes))
+	addEdge := func(from, to int) {
+		dependents[from] = append(dependents[from], to)
+		dependencies[to]++
+	}
+
+	for idx, node := range nodes {
+		for depIdx, dep := range nodes {
+			if kindRank(dep) < kindRank(node) {
+				addEdge(depIdx, idx)
+			}
+		}
+
+		if node.GetKind() != "CASTemplate" {
+			continue
+		}
+
+		for _, ref := range casTemplateReferences(node) {
+			refIdxs, found := byName[ref]
+			if !found {
+				available, err := isReferenceAvailable(node, ref, checkers)
+				if err != nil {
+					errs = append(errs, errors.Wrapf(err, "failed to resolve dependencies of '%s'", unstructuredKey(node)))
+				} else if !available {
+					errs = append(errs, fmt.Errorf("missing run task '%s': failed to resolve dependencies of '%s'", ref, unstructuredKey(node)))
+				}
+				continue
+			}
+			for _, refIdx := range refIdxs {
+				addEdge(refIdx, idx)
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	// group the nodes into levels while retaining the given order within a
+	// level
+	var current []int
+	for idx := range nodes {
+		if dependencies[idx] == 0 {
+			current = append(current, idx)
+		}
+	}
+
+	resolved := 0
+	for len(current) != 0 {
+		var level []*unstructured.Unstructured
+		var next []int
+		for _, idx := range current {
+			level = append(level, nodes[idx])
+			for _, dependent := range dependents[idx] {
+				dependencies[dependent]--
+				if dependencies[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+
+		resolved += len(level)
+		levels = append(levels, level)
+		sort.Ints(next)
+		current = next
+	}
+
+	if resolved != len(nodes) {
+		var cyclic []string
+		for idx, node := range nodes {
+			if dependencies[idx] != 0 {
+				cyclic = append(cyclic, unstructuredKey(node))
+			}
+		}
+		return nil, append(errs, fmt.Errorf("cyclic dependencies found among %v: failed to resolve dependencies", cyclic))
+	}
+
+	return
+}
+
+// isReferenceAvailable returns true if any of the given checkers finds the
+// run task referred to by the given CASTemplate
+func isReferenceAvailable(casTemplate *unstructured.Unstructured, runTask string, checkers []ReferenceChecker) (bool, error) {
+	for _, checker := range checkers {
+		found, err := checker(casTemplate, runTask)
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeCASTemplate returns a CASTemplate that refers to the given run tasks
+func fakeCASTemplate(name string, tasks ...string) *unstructured.Unstructured {
+	unstruct := fakeUnstructured("CASTemplate", name)
+	unstruct.SetNamespace("")
+	var refs []interface{}
+	for _, task := range tasks {
+		refs = append(refs, task)
+	}
+	unstructured.SetNestedField(unstruct.Object, "openebs", "spec", "taskNamespace")
+	unstructured.SetNestedSlice(unstruct.Object, refs, "spec", "run", "tasks")
+	return unstruct
+}
+
+// levelNames returns the names of the given levels' instances
+func levelNames(levels [][]*unstructured.Unstructured) (names [][]string) {
+	for _, level := range levels {
+		var namesOfLevel []string
+		for _, unstruct := range level {
+			namesOfLevel = append(namesOfLevel, unstruct.GetName())
+		}
+		names = append(names, namesOfLevel)
+	}
+	return
+}
+
+func TestResolveUnstructuredDependencies(t *testing.T) {
+	tests := map[string]struct {
+		list      []*unstructured.Unstructured
+		checkers  []ReferenceChecker
+		expected  string
+		expectErr string
+	}{
+		"kinds without dependencies": {
+			list:     []*unstructured.Unstructured{fakeUnstructured("ConfigMap", "a"), fakeUnstructured("ConfigMap", "b")},
+			expected: "[[a b]]",
+		},
+		"crd & namespace first": {
+			list: []*unstructured.Unstructured{
+				fakeUnstructured("ConfigMap", "a"),
+				fakeUnstructured("Namespace", "ns"),
+				fakeUnstructured("CustomResourceDefinition", "crd"),
+			},
+			expected: "[[crd] [ns] [a]]",
+		},
+		"cas template after its run tasks": {
+			list: []*unstructured.Unstructured{
+				fakeCASTemplate("cast", "rt1", "rt2"),
+				fakeUnstructured("ConfigMap", "rt2"),
+				fakeUnstructured("ConfigMap", "rt1"),
+				fakeUnstructured("ConfigMap", "other"),
+			},
+			expected: "[[rt2 rt1 other] [cast]]",
+		},
+		"missing run task": {
+			list:      []*unstructured.Unstructured{fakeCASTemplate("cast", "rt1")},
+			expectErr: "missing run task 'rt1'",
+		},
+		"missing run task found by checker": {
+			list: []*unstructured.Unstructured{fakeCASTemplate("cast", "rt1", "rt2"), fakeUnstructured("ConfigMap", "rt2")},
+			checkers: []ReferenceChecker{
+				func(casTemplate *unstructured.Unstructured, runTask string) (bool, error) {
+					return runTask == "rt1", nil
+				},
+			},
+			expected: "[[rt2] [cast]]",
+		},
+		"missing run task not found by checkers": {
+			list: []*unstructured.Unstructured{fakeCASTemplate("cast", "rt1")},
+			checkers: []ReferenceChecker{
+				func(casTemplate *unstructured.Unstructured, runTask string) (bool, error) {
+					return false, nil
+				},
+			},
+			expectErr: "missing run task 'rt1'",
+		},
+		"checker error": {
+			list: []*unstructured.Unstructured{fakeCASTemplate("cast", "rt1")},
+			checkers: []ReferenceChecker{
+				func(casTemplate *unstructured.Unstructured, runTask string) (bool, error) {
+					return false, fmt.Errorf("connection refused")
+				},
+			},
+			expectErr: "connection refused",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			levels, errs := ResolveUnstructuredDependenciesWith(mock.checkers...)(mock.list)
+			if len(mock.expectErr) != 0 {
+				if len(errs) != 1 || !strings.Contains(errs[0].Error(), mock.expectErr) {
+					t.Fatalf("expected error having '%s': got '%v'", mock.expectErr, errs)
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("expected no error: got '%v'", errs)
+			}
+
+			if got := fmt.Sprint(levelNames(levels)); got != mock.expected {
+				t.Fatalf("expected levels '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestSkippedReferenceChecker(t *testing.T) {
+	checker := SkippedReferenceChecker([]SkipReportItem{
+		{Kind: "RunTask", Name: "rt1"},
+		{Kind: "CASTemplate", Name: "rt2"},
+	})
+
+	tests := map[string]struct {
+		runTask  string
+		expected bool
+	}{
+		"skipped run task":             {runTask: "rt1", expected: true},
+		"skipped artifact of any kind": {runTask: "rt2"},
+		"not skipped":                  {runTask: "rt3"},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			found, err := checker(fakeCASTemplate("cast", mock.runTask), mock.runTask)
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if found != mock.expected {
+				t.Fatalf("expected found '%t': got '%t'", mock.expected, found)
+			}
+		})
+	}
+}
+
+func TestLiveReferenceChecker(t *testing.T) {
+	live := map[string]bool{"openebs/rt1": true}
+	getter := func(namespace string) k8s.ConfigMapGetter {
+		return k8s.ConfigMapGetterFunc(func(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+			if name == "broken" {
+				return nil, fmt.Errorf("connection refused")
+			}
+			if !live[namespace+"/"+name] {
+				return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+			}
+			return &corev1.ConfigMap{}, nil
+		})
+	}
+
+	withoutTaskNamespace := fakeCASTemplate("cast")
+	unstructured.RemoveNestedField(withoutTaskNamespace.Object, "spec", "taskNamespace")
+
+	tests := map[string]struct {
+		casTemplate *unstructured.Unstructured
+		runTask     string
+		expected    bool
+		expectErr   bool
+	}{
+		"available in task namespace": {casTemplate: fakeCASTemplate("cast"), runTask: "rt1", expected: true},
+		"not available":               {casTemplate: fakeCASTemplate("cast"), runTask: "rt2"},
+		"lookup error":                {casTemplate: fakeCASTemplate("cast"), runTask: "broken", expectErr: true},
+		"without any namespace":       {casTemplate: withoutTaskNamespace, runTask: "rt1"},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			found, err := LiveReferenceChecker(getter)(mock.casTemplate, mock.runTask)
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if found != mock.expected {
+				t.Fatalf("expected found '%t': got '%t'", mock.expected, found)
+			}
+		})
+	}
+}
+
+func TestUnstructuredLevelsWithSkippedRunTasks(t *testing.T) {
+	config := &InstallConfig{}
+	config.Spec.Install = []Install{{
+		Version: "0.7.0",
+		Include: []ArtifactSelector{{Kind: "CASTemplate"}},
+		Exclude: []ArtifactSelector{{Kind: "RunTask"}},
+	}}
+
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) { return config, nil })
+	levels, errs := i.unstructuredLevels(context.Background())
+	if len(errs) != 0 {
+		t.Fatalf("expected no error: got '%v'", errs)
+	}
+
+	var count int
+	for _, level := range levels {
+		for _, unstruct := range level {
+			if unstruct.GetKind() != "CASTemplate" {
+				t.Fatalf("expected only cas templates: got '%s'", unstructuredKey(unstruct))
+			}
+			count++
+		}
+	}
+	if count != 8 {
+		t.Fatalf("expected '8' cas templates: got '%d'", count)
+	}
+}
+
+func TestUnstructuredLevelsWithIncludedCASTemplates(t *testing.T) {
+	config := &InstallConfig{}
+	config.Spec.Install = []Install{{Version: "0.7.0", Include: []ArtifactSelector{{Kind: "CASTemplate"}}}}
+
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) { return config, nil })
+	levels, errs := i.unstructuredLevels(context.Background())
+	if len(errs) != 0 {
+		t.Fatalf("expected no error: got '%v'", errs)
+	}
+
+	// level at which every run task is installed
+	runTaskLevels := map[string]int{}
+	for idx, level := range levels {
+		for _, unstruct := range level {
+			if unstruct.GetKind() == "ConfigMap" {
+				runTaskLevels[unstruct.GetName()] = idx
+			}
+		}
+	}
+
+	var count int
+	for idx, level := range levels {
+		for _, unstruct := range level {
+			if unstruct.GetKind() != "CASTemplate" {
+				continue
+			}
+			count++
+
+			for _, ref := range casTemplateReferences(unstruct) {
+				runTaskLevel, found := runTaskLevels[ref]
+				if !found {
+					t.Fatalf("expected run task '%s' of '%s' to be installed", ref, unstruct.GetName())
+				}
+				if runTaskLevel >= idx {
+					t.Fatalf("expected run task '%s' to be installed before '%s'", ref, unstruct.GetName())
+				}
+			}
+		}
+	}
+	if count != 8 {
+		t.Fatalf("expected '8' cas templates: got '%d'", count)
+	}
+}
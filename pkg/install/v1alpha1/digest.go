@@ -0,0 +1,66 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// UnstructuredDigester abstracts computing the content digest of an
+// unstructured instance
+type UnstructuredDigester func(unstruct *unstructured.Unstructured) (digest string, err error)
+
+// DigestUnstructured returns the sha256 digest of the given unstructured
+// instance's content
+//
+// NOTE:
+//  The digest is stable since JSON marshalling sorts the keys of maps
+//
+// NOTE:
+//  The digest annotation if any is not part of the content so that the
+// digest of an annotated instance remains the same
+//
+// NOTE:
+//  This is an implementation of UnstructuredDigester
+func DigestUnstructured(unstruct *unstructured.Unstructured) (string, error) {
+	if unstruct == nil {
+		return "", fmt.Errorf("nil resource instance: failed to compute digest")
+	}
+
+	if _, found := unstruct.GetAnnotations()[string(ProvenanceAnnotationKeyDigest)]; found {
+		unstruct = unstruct.DeepCopy()
+		annotations := unstruct.GetAnnotations()
+		delete(annotations, string(ProvenanceAnnotationKeyDigest))
+		if len(annotations) == 0 {
+			unstructured.RemoveNestedField(unstruct.Object, "metadata", "annotations")
+		} else {
+			unstruct.SetAnnotations(annotations)
+		}
+	}
+
+	raw, err := json.Marshal(unstruct.Object)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to compute digest of '%s'", unstruct.GetName())
+	}
+
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(raw)), nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DriftMode is a typed string to represent what is done on detecting a
+// drift between the live and the desired resources
+type DriftMode string
+
+const (
+	// DriftModeReport only reports the drifted resources
+	DriftModeReport DriftMode = "report"
+	// DriftModeHeal reports as well as restores the drifted resources to their
+	// desired state
+	DriftModeHeal DriftMode = "heal"
+)
+
+// DriftModeFor returns the drift mode corresponding to the given value
+func DriftModeFor(mode string) (DriftMode, error) {
+	switch DriftMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case DriftModeReport, "":
+		return DriftModeReport, nil
+	case DriftModeHeal:
+		return DriftModeHeal, nil
+	default:
+		return "", fmt.Errorf("unsupported drift mode '%s': supported modes are '%s' and '%s'", mode, DriftModeReport, DriftModeHeal)
+	}
+}
+
+// DriftStatus is a typed string to represent the state of a live resource
+// when compared with its desired state
+type DriftStatus string
+
+const (
+	// DriftStatusInSync indicates the live resource is in its desired state
+	DriftStatusInSync DriftStatus = "in-sync"
+	// DriftStatusDrifted indicates the live resource differs from its desired
+	// state
+	DriftStatusDrifted DriftStatus = "drifted"
+	// DriftStatusMissing indicates the resource is not available in
+	// kubernetes cluster
+	DriftStatusMissing DriftStatus = "missing"
+	// DriftStatusUnknown indicates the state could not be determined
+	DriftStatusUnknown DriftStatus = "unknown"
+)
+
+// DriftItem is the drift of a single resource
+type DriftItem struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Namespace  string          `json:"namespace,omitempty"`
+	Name       string          `json:"name"`
+	Status     DriftStatus     `json:"status"`
+	Diffs      []k8s.FieldDiff `json:"diffs,omitempty"`
+	// Healed is true if the resource was restored to its desired state
+	Healed bool   `json:"healed,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DriftReport is the outcome of a drift detection run
+type DriftReport struct {
+	Mode      DriftMode   `json:"mode"`
+	Items     []DriftItem `json:"items"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Count returns the number of drift items with the given status
+func (r DriftReport) Count(status DriftStatus) (count int) {
+	for _, item := range r.Items {
+		if item.Status == status {
+			count++
+		}
+	}
+	return
+}
+
+// IsDrifted returns true if any of the resources is not in its desired
+// state or its state could not be determined
+//
+// NOTE:
+//  Resources that were healed are not considered as drifted
+func (r DriftReport) IsDrifted() bool {
+	for _, item := range r.Items {
+		if item.Status != DriftStatusInSync && !item.Healed {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode returns the process exit code corresponding to this report
+//
+// NOTE:
+//  A distinct exit code is used for drift so that it can be told apart from
+// a failure
+func (r DriftReport) ExitCode() int {
+	if r.IsDrifted() {
+		return 2
+	}
+	return 0
+}
+
+// JSON returns the JSON representation of this report
+func (r DriftReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// String returns the human readable representation of this report
+func (r DriftReport) String() string {
+	var buf bytes.Buffer
+
+	healed := 0
+	for _, item := range r.Items {
+		if item.Healed {
+			healed++
+		}
+
+		if item.Status == DriftStatusInSync {
+			continue
+		}
+
+		name := item.Name
+		if len(item.Namespace) != 0 {
+			name = item.Namespace + "/" + item.Name
+		}
+
+		status := string(item.Status)
+		if item.Healed {
+			status = status + " (healed)"
+		}
+
+		fmt.Fprintf(&buf, "%s %s/%s %s\n", status, item.APIVersion, item.Kind, name)
+		for _, diff := range item.Diffs {
+			fmt.Fprintf(&buf, "    %s\n", diff)
+		}
+
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, "    error: %s\n", item.Error)
+		}
+	}
+
+	fmt.Fprintf(&buf, "\nDrift: %d in-sync, %d drifted, %d missing, %d unknown, %d healed\n",
+		r.Count(DriftStatusInSync), r.Count(DriftStatusDrifted), r.Count(DriftStatusMissing), r.Count(DriftStatusUnknown), healed)
+
+	return buf.String()
+}
+
+// DriftDetector abstracts detecting the drift of live resources from their
+// desired state
+type DriftDetector interface {
+	DetectDrift(ctx context.Context, mode DriftMode) (report DriftReport, errors []error)
+}
+
+// detectUnstructuredDrift compares the given desired unstructured instance
+// with its live counterpart & heals the live resource if the mode is heal
+//
+// NOTE:
+//  The live resource is fetched as well as healed within the given context
+//
+// NOTE:
+//  Fields found only in the spec or data of the live resource e.g. a key
+// added to a live config map are considered as drift
+func detectUnstructuredDrift(ctx context.Context, desired *unstructured.Unstructured, mode DriftMode) (item DriftItem, err error) {
+	item = DriftItem{
+		APIVersion: desired.GetAPIVersion(),
+		Kind:       desired.GetKind(),
+		Namespace:  desired.GetNamespace(),
+		Name:       desired.GetName(),
+		Status:     DriftStatusUnknown,
+	}
+
+	gvr := GroupVersionResourceFromGVK(desired)
+	live, err := k8s.NewResourceGetterWithContext(ctx, gvr, desired.GetNamespace())(desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		item.Status = DriftStatusMissing
+	} else if err != nil {
+		err = errors.Wrapf(err, "failed to detect drift of resource '%s'", item.Name)
+		item.Error = err.Error()
+		return
+	} else {
+		item.Diffs = k8s.DiffUnstructuredWithLiveOnly(live, desired)
+		item.Status = DriftStatusInSync
+		if len(item.Diffs) != 0 {
+			item.Status = DriftStatusDrifted
+		}
+	}
+
+	if mode != DriftModeHeal || item.Status == DriftStatusInSync {
+		return item, nil
+	}
+
+	_, _, err = k8s.NewResourceActionApplierWithContext(ctx, gvr, desired.GetNamespace())(desired)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to heal drift of resource '%s'", item.Name)
+		item.Error = err.Error()
+		return
+	}
+	item.Healed = true
+
+	return item, nil
+}
+
+// DetectDrift compares the resources specified in the install config
+// against the live resources found in kubernetes cluster
+//
+// NOTE:
+//  Drifted as well as missing resources are restored to their desired state
+// if the mode is heal. Hooks are not considered since they are re-created on
+// every install. The resources are checked till the given context is done.
+//
+// NOTE:
+//  The number of drifted resources is recorded in the install metrics
+//
+// NOTE:
+//  This is an implementation of DriftDetector interface
+func (i *simpleInstaller) DetectDrift(ctx context.Context, mode DriftMode) (report DriftReport, errs []error) {
+	i.resetErrors()
+	report.Mode = mode
+	report.Timestamp = time.Now().UTC()
+
+	for _, unstruct := range i.orderedMainUnstructuredList(ctx) {
+		item, err := detectUnstructuredDrift(ctx, unstruct, mode)
+		if err != nil {
+			i.addError(err)
+		}
+		report.Items = append(report.Items, item)
+	}
+	observeDrift(report)
+
+	return report, i.errors
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDriftModeFor(t *testing.T) {
+	tests := map[string]struct {
+		value     string
+		expected  DriftMode
+		expectErr bool
+	}{
+		"empty defaults to report": {value: "", expected: DriftModeReport},
+		"report":                   {value: "report", expected: DriftModeReport},
+		"upper case heal":          {value: " HEAL ", expected: DriftModeHeal},
+		"unsupported":              {value: "fix", expectErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := DriftModeFor(mock.value)
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if got != mock.expected {
+				t.Fatalf("expected drift mode '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestDriftReportExitCode(t *testing.T) {
+	tests := map[string]struct {
+		items    []DriftItem
+		expected int
+	}{
+		"no items":       {expected: 0},
+		"in sync":        {items: []DriftItem{{Status: DriftStatusInSync}}, expected: 0},
+		"healed":         {items: []DriftItem{{Status: DriftStatusMissing, Healed: true}}, expected: 0},
+		"drifted":        {items: []DriftItem{{Status: DriftStatusInSync}, {Status: DriftStatusDrifted}}, expected: 2},
+		"unknown status": {items: []DriftItem{{Status: DriftStatusUnknown}}, expected: 2},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			report := DriftReport{Items: mock.items}
+			if got := report.ExitCode(); got != mock.expected {
+				t.Fatalf("expected exit code '%d': got '%d'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectDriftWhenResourcesCanNotBeFetched(t *testing.T) {
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+		return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+	})
+	InstallDriftedObjects.Set(0)
+
+	// live resources can not be fetched in the absence of a kubernetes cluster
+	report, errs := i.DetectDrift(context.Background(), DriftModeReport)
+	if len(report.Items) == 0 {
+		t.Fatalf("expected drift items: got none")
+	}
+	if report.Count(DriftStatusInSync) != 0 {
+		t.Skip("expected live resources to be not fetched in the absence of a kubernetes cluster")
+	}
+	if len(errs) != len(report.Items) || report.Count(DriftStatusUnknown) != len(report.Items) {
+		t.Fatalf("expected every resource to be of unknown drift: got '%d' unknown & '%d' errors for '%d' items",
+			report.Count(DriftStatusUnknown), len(errs), len(report.Items))
+	}
+	if got := testutil.ToFloat64(InstallDriftedObjects); got != float64(len(report.Items)) {
+		t.Fatalf("expected '%d' drifted objects metric: got '%v'", len(report.Items), got)
+	}
+
+	// errors of a previous run are not reported again
+	_, again := i.DetectDrift(context.Background(), DriftModeReport)
+	if len(again) != len(errs) {
+		t.Fatalf("expected '%d' errors: got '%d'", len(errs), len(again))
+	}
+}
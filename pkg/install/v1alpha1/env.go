@@ -31,4 +31,49 @@ const (
 	// EnvKeyForInstallConfigNamespace is the environment variable to get
 	// the install config's namespace
 	EnvKeyForInstallConfigNamespace InstallENVKey = InstallENVKey(string(commonenv.EnvKeyForOpenEBSNamespace))
+	// EnvKeyForInstallConcurrency is the environment variable to get the
+	// number of resources that can be applied concurrently
+	EnvKeyForInstallConcurrency InstallENVKey = "OPENEBS_IO_INSTALL_CONCURRENCY"
+	// EnvKeyForInstallTransactional is the environment variable to determine
+	// if a failed install should be rolled back
+	EnvKeyForInstallTransactional InstallENVKey = "OPENEBS_IO_INSTALL_TRANSACTIONAL"
+	// EnvKeyForInstallWaitForReadiness is the environment variable to
+	// determine if install should wait for the applied resources to be ready
+	EnvKeyForInstallWaitForReadiness InstallENVKey = "OPENEBS_IO_INSTALL_WAIT_FOR_READINESS"
+	// EnvKeyForInstallReadinessTimeout is the environment variable to get the
+	// maximum time to wait for a resource to be ready e.g. 5m
+	EnvKeyForInstallReadinessTimeout InstallENVKey = "OPENEBS_IO_INSTALL_READINESS_TIMEOUT"
+	// EnvKeyForInstallResyncInterval is the environment variable to get the
+	// interval at which install controller re-runs install even if the
+	// install config has not changed e.g. 10m
+	EnvKeyForInstallResyncInterval InstallENVKey = "OPENEBS_IO_INSTALL_RESYNC_INTERVAL"
+	// EnvKeyForInstallPrune is the environment variable to determine if
+	// install should delete the resources that are no longer specified in the
+	// install config
+	EnvKeyForInstallPrune InstallENVKey = "OPENEBS_IO_INSTALL_PRUNE"
+	// EnvKeyForInstallMetricsAddress is the environment variable to get the
+	// address to serve the install metrics at e.g. :9500; metrics are not
+	// served if this is not set
+	EnvKeyForInstallMetricsAddress InstallENVKey = "OPENEBS_IO_INSTALL_METRICS_ADDRESS"
+	// EnvKeyForInstallLeaderElection is the environment variable to
+	// determine if install controller should reconcile only after being
+	// elected as the leader amongst its replicas
+	EnvKeyForInstallLeaderElection InstallENVKey = "OPENEBS_IO_INSTALL_LEADER_ELECTION"
+	// EnvKeyForInstallLeaderIdentity is the environment variable to get the
+	// identity of the install controller replica during leader election;
+	// defaults to the host name
+	EnvKeyForInstallLeaderIdentity InstallENVKey = "OPENEBS_IO_INSTALL_LEADER_IDENTITY"
+	// EnvKeyForInstallTimeout is the environment variable to get the
+	// maximum time an install run may take e.g. 10m; an install run is not
+	// bound by time if this is not set
+	EnvKeyForInstallTimeout InstallENVKey = "OPENEBS_IO_INSTALL_TIMEOUT"
+	// EnvKeyForInstallObjectTimeout is the environment variable to get the
+	// maximum time to apply a single resource e.g. 30s; applying a resource
+	// is not bound by time if this is not set
+	EnvKeyForInstallObjectTimeout InstallENVKey = "OPENEBS_IO_INSTALL_OBJECT_TIMEOUT"
+	// EnvKeyForInstallDriftMode is the environment variable to get what
+	// install controller does on detecting a drift at every resync i.e.
+	// report or heal; defaults to heal. The drift that is reported is healed
+	// by re-running install.
+	EnvKeyForInstallDriftMode InstallENVKey = "OPENEBS_IO_INSTALL_DRIFT_MODE"
 )
@@ -0,0 +1,305 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InstallOutcome is a typed string to represent the outcome of an install
+// run
+type InstallOutcome string
+
+const (
+	// InstallOutcomeSucceeded indicates all the resources were installed
+	InstallOutcomeSucceeded InstallOutcome = "succeeded"
+	// InstallOutcomeFailed indicates one or more resources were not installed
+	InstallOutcomeFailed InstallOutcome = "failed"
+)
+
+// maxInstallHistoryEntries is the number of latest install runs that are
+// retained in the install history
+const maxInstallHistoryEntries = 30
+
+// InstallHistoryArtifact is an artifact that was applied during an install
+// run
+type InstallHistoryArtifact struct {
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Digest    string        `json:"digest,omitempty"`
+	Action    InstallAction `json:"action"`
+}
+
+// InstallHistoryEntry is the record of a single install run
+type InstallHistoryEntry struct {
+	// ID identifies this install run unambiguously
+	ID string `json:"id"`
+	// Revision is the sequence number of this install run; it is one more
+	// than the revision of the previous install run
+	Revision int64 `json:"revision"`
+	// ConfigName is the name of the install config
+	ConfigName string `json:"configName"`
+	// ConfigDigest is the digest of the install config specs used by this
+	// run
+	ConfigDigest string `json:"configDigest,omitempty"`
+	// ConfigResourceVersion is the resource version of the install config
+	// map used by this run
+	ConfigResourceVersion string `json:"configResourceVersion,omitempty"`
+	// Versions that were installed
+	Versions []string `json:"versions"`
+	// Artifacts that were applied
+	Artifacts []InstallHistoryArtifact `json:"artifacts"`
+	// Outcome of this install run
+	Outcome InstallOutcome `json:"outcome"`
+	// Timestamp of this install run
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewInstallHistoryEntry returns a new install history entry based on the
+// given install config & the report of the install run
+func NewInstallHistoryEntry(configName string, config *InstallConfig, report InstallReport) (entry InstallHistoryEntry) {
+	entry.Timestamp = time.Now().UTC()
+	entry.ID = fmt.Sprintf("run-%d", entry.Timestamp.UnixNano())
+	entry.ConfigName = configName
+	entry.Outcome = InstallOutcomeSucceeded
+	if !report.IsSuccess() {
+		entry.Outcome = InstallOutcomeFailed
+	}
+
+	if config != nil {
+		if raw, err := json.Marshal(config.Spec); err == nil {
+			entry.ConfigDigest = fmt.Sprintf("sha256:%x", sha256.Sum256(raw))
+		}
+		entry.ConfigResourceVersion = config.ResourceVersion
+		for _, install := range config.Spec.Install {
+			entry.Versions = append(entry.Versions, install.Version)
+		}
+	}
+
+	for _, item := range report.Items {
+		entry.Artifacts = append(entry.Artifacts, InstallHistoryArtifact{
+			Kind:      item.Kind,
+			Namespace: item.Namespace,
+			Name:      item.Name,
+			Digest:    item.Digest,
+			Action:    item.Action,
+		})
+	}
+
+	return
+}
+
+// InstallHistoryRecorder abstracts recording of an install run
+type InstallHistoryRecorder interface {
+	Record(ctx context.Context, entry InstallHistoryEntry) error
+}
+
+// InstallHistory abstracts recording as well as fetching of install runs
+type InstallHistory interface {
+	InstallHistoryRecorder
+	// List returns the recorded install runs with the latest run first
+	List(ctx context.Context) ([]InstallHistoryEntry, error)
+	// Get returns the install run with the given id
+	Get(ctx context.Context, id string) (InstallHistoryEntry, error)
+}
+
+// InstallHistoryName returns the name of the ConfigMap that records the
+// install runs of the given install config
+func InstallHistoryName(configName string) string {
+	return configName + "-history"
+}
+
+// configMapInstallHistory records install runs in a ConfigMap where every
+// run is a data key
+//
+// NOTE:
+//  This is an implementation of InstallHistory. The ConfigMap clients are
+// built for every call so that they honour the context of the caller.
+type configMapInstallHistory struct {
+	name      string
+	namespace string
+	getter    func(ctx context.Context, namespace string) k8s.ConfigMapGetter
+	creator   func(ctx context.Context, namespace string) k8s.ConfigMapCreator
+	updater   func(ctx context.Context, namespace string) k8s.ConfigMapUpdater
+}
+
+// NewConfigMapInstallHistory returns a new instance of InstallHistory that
+// records install runs in a ConfigMap placed next to the install config
+func NewConfigMapInstallHistory(namespace, configName string) InstallHistory {
+	return &configMapInstallHistory{
+		name:      InstallHistoryName(configName),
+		namespace: namespace,
+		getter:    k8s.NewConfigMapGetterWithContext,
+		creator:   k8s.NewConfigMapCreatorWithContext,
+		updater:   k8s.NewConfigMapUpdaterWithContext,
+	}
+}
+
+// Record adds the given install run to the install history
+//
+// NOTE:
+//  The revision of the given install run is set to one more than that of the
+// latest recorded install run
+//
+// NOTE:
+//  Only the latest install runs are retained. The install run is recorded
+// within the given context.
+func (h *configMapInstallHistory) Record(ctx context.Context, entry InstallHistoryEntry) error {
+	// retry on conflicts since install runs may be recorded concurrently
+	for attempt := 0; attempt < 3; attempt++ {
+		cm, err := h.getter(ctx, h.namespace).Get(h.name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to record install run '%s'", entry.ID)
+		}
+
+		var data map[string]string
+		if err == nil {
+			data = cm.Data
+		} else {
+			cm = nil
+		}
+		entry.Revision = latestInstallHistoryRevision(data) + 1
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return errors.Wrapf(err, "failed to record install run '%s'", entry.ID)
+		}
+
+		if cm == nil {
+			_, err = h.creator(ctx, h.namespace).Create(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: h.name},
+				Data:       map[string]string{entry.ID: string(raw)},
+			})
+			if apierrors.IsAlreadyExists(err) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to record install run '%s'", entry.ID)
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[entry.ID] = string(raw)
+		pruneInstallHistory(cm.Data)
+
+		_, err = h.updater(ctx, h.namespace).Update(cm)
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		return errors.Wrapf(err, "failed to record install run '%s'", entry.ID)
+	}
+
+	return fmt.Errorf("too many conflicts: failed to record install run '%s'", entry.ID)
+}
+
+// latestInstallHistoryRevision returns the revision of the latest install run
+// found in the given install history data; zero if there is none
+//
+// NOTE:
+//  Install runs recorded without a revision are counted in the order they
+// were recorded
+func latestInstallHistoryRevision(data map[string]string) (revision int64) {
+	ids := sortedInstallHistoryIDs(data)
+	if len(ids) == 0 {
+		return 0
+	}
+
+	var latest InstallHistoryEntry
+	if err := json.Unmarshal([]byte(data[ids[0]]), &latest); err != nil || latest.Revision == 0 {
+		return int64(len(ids))
+	}
+	return latest.Revision
+}
+
+// pruneInstallHistory removes the oldest install runs beyond the retention
+// limit
+func pruneInstallHistory(data map[string]string) {
+	ids := sortedInstallHistoryIDs(data)
+	for len(ids) > maxInstallHistoryEntries {
+		delete(data, ids[len(ids)-1])
+		ids = ids[:len(ids)-1]
+	}
+}
+
+// sortedInstallHistoryIDs returns the ids of the install runs with the
+// latest run first
+func sortedInstallHistoryIDs(data map[string]string) (ids []string) {
+	for id := range data {
+		if strings.HasPrefix(id, "run-") {
+			ids = append(ids, id)
+		}
+	}
+
+	// ids embed the timestamp in nano seconds which are of equal length
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return
+}
+
+// List returns the recorded install runs with the latest run first
+func (h *configMapInstallHistory) List(ctx context.Context) (entries []InstallHistoryEntry, err error) {
+	cm, err := h.getter(ctx, h.namespace).Get(h.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list install runs")
+	}
+
+	for _, id := range sortedInstallHistoryIDs(cm.Data) {
+		var entry InstallHistoryEntry
+		err = json.Unmarshal([]byte(cm.Data[id]), &entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list install runs: invalid install run '%s'", id)
+		}
+		entries = append(entries, entry)
+	}
+
+	return
+}
+
+// Get returns the install run with the given id
+func (h *configMapInstallHistory) Get(ctx context.Context, id string) (entry InstallHistoryEntry, err error) {
+	cm, err := h.getter(ctx, h.namespace).Get(h.name, metav1.GetOptions{})
+	if err != nil {
+		return entry, errors.Wrapf(err, "failed to get install run '%s'", id)
+	}
+
+	raw, found := cm.Data[id]
+	if !found {
+		return entry, fmt.Errorf("install run '%s' not found: failed to get install run", id)
+	}
+
+	err = json.Unmarshal([]byte(raw), &entry)
+	if err != nil {
+		return entry, errors.Wrapf(err, "failed to get install run '%s'", id)
+	}
+
+	return
+}
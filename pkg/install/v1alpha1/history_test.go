@@ -0,0 +1,175 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeInstallHistory returns an install history backed by an in-memory
+// ConfigMap
+func fakeInstallHistory() *configMapInstallHistory {
+	var stored *corev1.ConfigMap
+	notFound := apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "fake-history")
+	getter := k8s.ConfigMapGetterFunc(func(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+		if stored == nil {
+			return nil, notFound
+		}
+		return stored.DeepCopy(), nil
+	})
+	creator := k8s.ConfigMapCreatorFunc(func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		stored = cm.DeepCopy()
+		return cm, nil
+	})
+	updater := k8s.ConfigMapUpdaterFunc(func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		// status annotations bump the resource version in between runs
+		stored = cm.DeepCopy()
+		stored.ResourceVersion = fmt.Sprintf("%d", len(stored.Data)*7)
+		return cm, nil
+	})
+	return &configMapInstallHistory{
+		name:    "fake-history",
+		getter:  func(ctx context.Context, namespace string) k8s.ConfigMapGetter { return getter },
+		creator: func(ctx context.Context, namespace string) k8s.ConfigMapCreator { return creator },
+		updater: func(ctx context.Context, namespace string) k8s.ConfigMapUpdater { return updater },
+	}
+}
+
+func TestInstallHistoryRecord(t *testing.T) {
+	tests := map[string]struct {
+		runs             int
+		expectedCount    int
+		expectedRevision int64
+	}{
+		"first run":             {runs: 1, expectedCount: 1, expectedRevision: 1},
+		"few runs":              {runs: 5, expectedCount: 5, expectedRevision: 5},
+		"runs beyond retention": {runs: maxInstallHistoryEntries + 5, expectedCount: maxInstallHistoryEntries, expectedRevision: maxInstallHistoryEntries + 5},
+	}
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := fakeInstallHistory()
+			for run := 0; run < mock.runs; run++ {
+				entry := InstallHistoryEntry{ID: fmt.Sprintf("run-%019d", run+1)}
+				if err := h.Record(context.Background(), entry); err != nil {
+					t.Fatalf("expected no error: got '%v'", err)
+				}
+			}
+
+			entries, err := h.List(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if len(entries) != mock.expectedCount {
+				t.Fatalf("expected '%d' install runs: got '%d'", mock.expectedCount, len(entries))
+			}
+			if entries[0].Revision != mock.expectedRevision {
+				t.Fatalf("expected latest revision '%d': got '%d'", mock.expectedRevision, entries[0].Revision)
+			}
+			for idx := 1; idx < len(entries); idx++ {
+				if entries[idx-1].Revision != entries[idx].Revision+1 {
+					t.Fatalf("expected sequential revisions: got '%d' after '%d'", entries[idx-1].Revision, entries[idx].Revision)
+				}
+			}
+		})
+	}
+}
+
+func TestLatestInstallHistoryRevision(t *testing.T) {
+	tests := map[string]struct {
+		data     map[string]string
+		expected int64
+	}{
+		"no history":        {data: nil, expected: 0},
+		"non run keys":      {data: map[string]string{"other": "{}"}, expected: 0},
+		"with revisions":    {data: map[string]string{"run-1": `{"revision": 4}`, "run-2": `{"revision": 5}`}, expected: 5},
+		"without revisions": {data: map[string]string{"run-1": `{}`, "run-2": `{}`, "run-3": `{}`}, expected: 3},
+	}
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := latestInstallHistoryRevision(mock.data); got != mock.expected {
+				t.Fatalf("expected revision '%d': got '%d'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewInstallHistoryEntry(t *testing.T) {
+	one := &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}
+	two := &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.8.0"}}}}
+	failed := newInstallReport([]InstallReportItem{{Kind: "ConfigMap", Name: "one", Action: InstallActionFailed}}, nil)
+
+	tests := map[string]struct {
+		config          *InstallConfig
+		report          InstallReport
+		expectedOutcome InstallOutcome
+		expectedDigest  bool
+	}{
+		"succeeded run":        {config: one, report: InstallReport{}, expectedOutcome: InstallOutcomeSucceeded, expectedDigest: true},
+		"failed run":           {config: one, report: failed, expectedOutcome: InstallOutcomeFailed, expectedDigest: true},
+		"run without a config": {config: nil, report: failed, expectedOutcome: InstallOutcomeFailed},
+	}
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			entry := NewInstallHistoryEntry("install-config", mock.config, mock.report)
+			if entry.Outcome != mock.expectedOutcome {
+				t.Fatalf("expected outcome '%s': got '%s'", mock.expectedOutcome, entry.Outcome)
+			}
+			if (entry.ConfigDigest != "") != mock.expectedDigest {
+				t.Fatalf("expected config digest '%t': got '%s'", mock.expectedDigest, entry.ConfigDigest)
+			}
+			if len(entry.Artifacts) != len(mock.report.Items) {
+				t.Fatalf("expected '%d' artifacts: got '%d'", len(mock.report.Items), len(entry.Artifacts))
+			}
+		})
+	}
+
+	if NewInstallHistoryEntry("", one, InstallReport{}).ConfigDigest == NewInstallHistoryEntry("", two, InstallReport{}).ConfigDigest {
+		t.Fatalf("expected different config digests for different install configs: got same")
+	}
+}
+
+func TestNewInstallHistoryEntryResourceVersion(t *testing.T) {
+	getter := k8s.ConfigMapGetterFunc(func(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: "42"},
+			Data:       map[string]string{"install": "spec:\n  install:\n  - version: 0.7.0\n"},
+		}, nil
+	})
+
+	config, err := WithConfigMapConfigGetter(getter)("install-config")
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	entry := NewInstallHistoryEntry("install-config", config, InstallReport{})
+	if entry.ConfigResourceVersion != "42" {
+		t.Fatalf("expected config resource version '42': got '%s'", entry.ConfigResourceVersion)
+	}
+
+	file := NewInstallHistoryEntry("install-config", &InstallConfig{Spec: config.Spec}, InstallReport{})
+	if file.ConfigResourceVersion != "" || file.ConfigDigest != entry.ConfigDigest {
+		t.Fatalf("expected same digest without resource version: got '%s' with digest '%s'", file.ConfigResourceVersion, file.ConfigDigest)
+	}
+}
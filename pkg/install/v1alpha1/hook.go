@@ -0,0 +1,356 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// HookAnnotationKey is a typed string to represent the annotations that mark
+// an artifact as a hook
+type HookAnnotationKey string
+
+const (
+	// HookAnnotationKeyPhase is the annotation that has the phase the hook
+	// runs in i.e. pre-install or post-install
+	HookAnnotationKeyPhase HookAnnotationKey = "install.openebs.io/hook"
+	// HookAnnotationKeyWeight is the annotation that has the weight of the
+	// hook; hooks of a phase run in the ascending order of their weights
+	HookAnnotationKeyWeight HookAnnotationKey = "install.openebs.io/hook-weight"
+	// HookAnnotationKeyDeletePolicy is the annotation that has the comma
+	// separated delete policies of the hook
+	HookAnnotationKeyDeletePolicy HookAnnotationKey = "install.openebs.io/hook-delete-policy"
+	// HookAnnotationKeyWait is the annotation that determines if the hook is
+	// waited upon till it completes; defaults to true
+	HookAnnotationKeyWait HookAnnotationKey = "install.openebs.io/hook-wait"
+	// HookAnnotationKeyTimeout is the annotation that has the maximum time to
+	// wait for the hook to complete e.g. 5m
+	HookAnnotationKeyTimeout HookAnnotationKey = "install.openebs.io/hook-timeout"
+)
+
+// HookPhase is a typed string to represent when a hook is run
+type HookPhase string
+
+const (
+	// HookPhasePreInstall hooks run before the artifacts are installed
+	HookPhasePreInstall HookPhase = "pre-install"
+	// HookPhasePostInstall hooks run after the artifacts are installed
+	HookPhasePostInstall HookPhase = "post-install"
+)
+
+// HookDeletePolicy is a typed string to represent when a hook is deleted
+type HookDeletePolicy string
+
+const (
+	// HookDeleteBeforeCreation deletes the previous instance of the hook
+	// before the hook is created; this is the default policy
+	HookDeleteBeforeCreation HookDeletePolicy = "before-hook-creation"
+	// HookDeleteOnSuccess deletes the hook after it succeeds
+	HookDeleteOnSuccess HookDeletePolicy = "hook-succeeded"
+	// HookDeleteOnFailure deletes the hook after it fails
+	HookDeleteOnFailure HookDeletePolicy = "hook-failed"
+)
+
+// defaultHookTimeout is the maximum time to wait for a hook to complete if
+// not specified
+const defaultHookTimeout = 5 * time.Minute
+
+// hookPhase returns the phase of the given unstructured instance; empty if
+// it is not a hook
+func hookPhase(unstruct *unstructured.Unstructured) HookPhase {
+	return HookPhase(strings.TrimSpace(unstruct.GetAnnotations()[string(HookAnnotationKeyPhase)]))
+}
+
+// hookWeight returns the weight of the given hook; defaults to 0
+func hookWeight(hook *unstructured.Unstructured) int {
+	weight, _ := strconv.Atoi(strings.TrimSpace(hook.GetAnnotations()[string(HookAnnotationKeyWeight)]))
+	return weight
+}
+
+// hookDeletePolicies returns the delete policies of the given hook
+func hookDeletePolicies(hook *unstructured.Unstructured) map[HookDeletePolicy]bool {
+	policies := map[HookDeletePolicy]bool{}
+	for _, policy := range strings.Split(hook.GetAnnotations()[string(HookAnnotationKeyDeletePolicy)], ",") {
+		policy = strings.TrimSpace(policy)
+		if len(policy) != 0 {
+			policies[HookDeletePolicy(policy)] = true
+		}
+	}
+
+	if len(policies) == 0 {
+		policies[HookDeleteBeforeCreation] = true
+	}
+	return policies
+}
+
+// isHookWait returns true if the given hook should be waited upon till it
+// completes
+func isHookWait(hook *unstructured.Unstructured) bool {
+	wait, err := strconv.ParseBool(strings.TrimSpace(hook.GetAnnotations()[string(HookAnnotationKeyWait)]))
+	if err != nil {
+		return true
+	}
+	return wait
+}
+
+// hookTimeout returns the maximum time to wait for the given hook to
+// complete
+func hookTimeout(hook *unstructured.Unstructured) time.Duration {
+	timeout, err := time.ParseDuration(strings.TrimSpace(hook.GetAnnotations()[string(HookAnnotationKeyTimeout)]))
+	if err != nil || timeout <= 0 {
+		return defaultHookTimeout
+	}
+	return timeout
+}
+
+// validateHooks returns an error for every hook having an unsupported phase
+func validateHooks(hooks []*unstructured.Unstructured) (errs []error) {
+	for _, hook := range hooks {
+		phase := hookPhase(hook)
+		if phase != HookPhasePreInstall && phase != HookPhasePostInstall {
+			errs = append(errs, fmt.Errorf("unsupported hook phase '%s': invalid hook '%s'", phase, hook.GetName()))
+		}
+	}
+	return
+}
+
+// separateHooks returns the given unstructured instances without the hooks
+// & the hooks separately
+func separateHooks(list []*unstructured.Unstructured) (main, hooks []*unstructured.Unstructured) {
+	for _, unstruct := range list {
+		if unstruct != nil && len(hookPhase(unstruct)) != 0 {
+			hooks = append(hooks, unstruct)
+			continue
+		}
+		main = append(main, unstruct)
+	}
+	return
+}
+
+// hooksFor returns the hooks of the given phase in the order they need to be
+// run
+//
+// NOTE:
+//  Hooks of same weight retain their order in the install config
+func hooksFor(phase HookPhase, hooks []*unstructured.Unstructured) (phased []*unstructured.Unstructured) {
+	for _, hook := range hooks {
+		if hookPhase(hook) == phase {
+			phased = append(phased, hook)
+		}
+	}
+
+	sort.SliceStable(phased, func(a, b int) bool {
+		return hookWeight(phased[a]) < hookWeight(phased[b])
+	})
+	return
+}
+
+// HookCompletionChecker abstracts checking if a live hook has completed
+type HookCompletionChecker func(live *unstructured.Unstructured) (done bool, err error)
+
+// IsJobComplete returns true if the given Job has succeeded; an error if the
+// Job has failed
+//
+// NOTE:
+//  This is an implementation of HookCompletionChecker
+func IsJobComplete(live *unstructured.Unstructured) (bool, error) {
+	failed, err := hasConditionStatus(live, "Failed", "True")
+	if err != nil {
+		return false, err
+	}
+	if failed {
+		return false, fmt.Errorf("job '%s' has failed", live.GetName())
+	}
+
+	return hasConditionStatus(live, "Complete", "True")
+}
+
+// IsPodComplete returns true if the given Pod has succeeded; an error if the
+// Pod has failed
+//
+// NOTE:
+//  This is an implementation of HookCompletionChecker
+func IsPodComplete(live *unstructured.Unstructured) (bool, error) {
+	phase, _, _ := unstructured.NestedString(live.Object, "status", "phase")
+	if phase == "Failed" {
+		return false, fmt.Errorf("pod '%s' has failed", live.GetName())
+	}
+	return phase == "Succeeded", nil
+}
+
+// hookCompletionCheckers maps a kind to its completion checker; hooks of
+// other kinds are complete once they are created
+var hookCompletionCheckers = map[string]HookCompletionChecker{
+	"Job": IsJobComplete,
+	"Pod": IsPodComplete,
+}
+
+// HookReportItem is the outcome of running a single hook
+type HookReportItem struct {
+	Phase     HookPhase     `json:"phase"`
+	Weight    int           `json:"weight"`
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Succeeded bool          `json:"succeeded"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// runHooks runs the given hooks of the given phase one after the other
+//
+// NOTE:
+//  The remaining hooks are not run once a hook fails or once the given
+// context is done
+func runHooks(ctx context.Context, phase HookPhase, hooks []*unstructured.Unstructured) (items []HookReportItem, ok bool) {
+	for _, hook := range hooksFor(phase, hooks) {
+		if ctx.Err() != nil {
+			return items, false
+		}
+
+		item := runHook(ctx, phase, hook)
+		items = append(items, item)
+		if !item.Succeeded {
+			return items, false
+		}
+	}
+	return items, true
+}
+
+// runHook creates the given hook & waits for its completion as per its
+// annotations
+func runHook(ctx context.Context, phase HookPhase, hook *unstructured.Unstructured) HookReportItem {
+	item := HookReportItem{
+		Phase:     phase,
+		Weight:    hookWeight(hook),
+		Kind:      hook.GetKind(),
+		Namespace: hook.GetNamespace(),
+		Name:      hook.GetName(),
+	}
+
+	start := time.Now()
+	policies := hookDeletePolicies(hook)
+
+	err := executeHook(ctx, hook, policies)
+	item.Duration = time.Since(start)
+	item.Succeeded = err == nil
+
+	if (item.Succeeded && policies[HookDeleteOnSuccess]) || (!item.Succeeded && policies[HookDeleteOnFailure]) {
+		// the hook is cleaned up even if the context is done
+		delErr := deleteHook(context.Background(), hook, false)
+		if delErr != nil && err == nil {
+			err = delErr
+		}
+	}
+
+	if err != nil {
+		item.Error = errors.Wrapf(err, "%s hook '%s' failed", phase, hook.GetName()).Error()
+	}
+
+	return item
+}
+
+// executeHook creates the given hook & waits for its completion if needed
+func executeHook(ctx context.Context, hook *unstructured.Unstructured, policies map[HookDeletePolicy]bool) error {
+	gvr := GroupVersionResourceFromGVK(hook)
+
+	if policies[HookDeleteBeforeCreation] {
+		err := deleteHook(ctx, hook, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := k8s.NewResourceCreatorWithContext(ctx, gvr, hook.GetNamespace())(hook.DeepCopy())
+	if err != nil {
+		return errors.Wrap(err, "failed to create hook")
+	}
+
+	check, found := hookCompletionCheckers[hook.GetKind()]
+	if !found || !isHookWait(hook) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout(hook))
+	defer cancel()
+
+	get := k8s.NewResourceGetterWithContext(ctx, gvr, hook.GetNamespace())
+
+	var lastErr error
+	err = wait.PollImmediateUntil(defaultReadinessInterval, func() (bool, error) {
+		live, err := get(hook.GetName(), metav1.GetOptions{})
+		if err != nil {
+			// the hook may not be visible yet
+			lastErr = err
+			return false, nil
+		}
+
+		// a failed hook stops the polling
+		return check(live)
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		err = lastErr
+	}
+
+	return errors.Wrap(err, "failed to wait for hook completion")
+}
+
+// deleteHook deletes the given hook along with its dependents e.g. the pods
+// of a Job & optionally waits till it is gone
+func deleteHook(ctx context.Context, hook *unstructured.Unstructured, waitTillGone bool) error {
+	gvr := GroupVersionResourceFromGVK(hook)
+	propagation := metav1.DeletePropagationBackground
+
+	err := k8s.NewResourceDeleterWithContext(ctx, gvr, hook.GetNamespace())(hook.GetName(), &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "failed to delete hook")
+	}
+
+	if !waitTillGone {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout(hook))
+	defer cancel()
+
+	get := k8s.NewResourceGetterWithContext(ctx, gvr, hook.GetNamespace())
+	err = wait.PollImmediateUntil(defaultReadinessInterval, func() (bool, error) {
+		_, err := get(hook.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, nil
+	}, ctx.Done())
+
+	return errors.Wrap(err, "failed to wait for hook deletion")
+}
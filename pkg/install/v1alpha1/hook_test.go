@@ -0,0 +1,249 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fakeHook returns a Job hook having the given annotations
+func fakeHook(name string, annotations map[string]string) *unstructured.Unstructured {
+	hook := fakeUnstructured("Job", name)
+	hook.SetAnnotations(annotations)
+	return hook
+}
+
+func TestHookAnnotations(t *testing.T) {
+	tests := map[string]struct {
+		annotations    map[string]string
+		expectPhase    HookPhase
+		expectWeight   int
+		expectPolicies map[HookDeletePolicy]bool
+		expectWait     bool
+		expectTimeout  time.Duration
+	}{
+		"not a hook": {
+			expectPolicies: map[HookDeletePolicy]bool{HookDeleteBeforeCreation: true},
+			expectWait:     true,
+			expectTimeout:  defaultHookTimeout,
+		},
+		"hook with defaults": {
+			annotations:    map[string]string{string(HookAnnotationKeyPhase): " pre-install "},
+			expectPhase:    HookPhasePreInstall,
+			expectPolicies: map[HookDeletePolicy]bool{HookDeleteBeforeCreation: true},
+			expectWait:     true,
+			expectTimeout:  defaultHookTimeout,
+		},
+		"hook with all annotations": {
+			annotations: map[string]string{
+				string(HookAnnotationKeyPhase):        "post-install",
+				string(HookAnnotationKeyWeight):       "-5",
+				string(HookAnnotationKeyDeletePolicy): "hook-succeeded, hook-failed",
+				string(HookAnnotationKeyWait):         "false",
+				string(HookAnnotationKeyTimeout):      "30s",
+			},
+			expectPhase:    HookPhasePostInstall,
+			expectWeight:   -5,
+			expectPolicies: map[HookDeletePolicy]bool{HookDeleteOnSuccess: true, HookDeleteOnFailure: true},
+			expectTimeout:  30 * time.Second,
+		},
+		"hook with invalid annotations": {
+			annotations: map[string]string{
+				string(HookAnnotationKeyPhase):        "post-install",
+				string(HookAnnotationKeyWeight):       "heavy",
+				string(HookAnnotationKeyDeletePolicy): " , ",
+				string(HookAnnotationKeyWait):         "maybe",
+				string(HookAnnotationKeyTimeout):      "-1m",
+			},
+			expectPhase:    HookPhasePostInstall,
+			expectPolicies: map[HookDeletePolicy]bool{HookDeleteBeforeCreation: true},
+			expectWait:     true,
+			expectTimeout:  defaultHookTimeout,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			hook := fakeHook("hook", mock.annotations)
+			if got := hookPhase(hook); got != mock.expectPhase {
+				t.Fatalf("expected phase '%s': got '%s'", mock.expectPhase, got)
+			}
+			if got := hookWeight(hook); got != mock.expectWeight {
+				t.Fatalf("expected weight '%d': got '%d'", mock.expectWeight, got)
+			}
+			if got := hookDeletePolicies(hook); !reflect.DeepEqual(got, mock.expectPolicies) {
+				t.Fatalf("expected delete policies '%v': got '%v'", mock.expectPolicies, got)
+			}
+			if got := isHookWait(hook); got != mock.expectWait {
+				t.Fatalf("expected wait '%t': got '%t'", mock.expectWait, got)
+			}
+			if got := hookTimeout(hook); got != mock.expectTimeout {
+				t.Fatalf("expected timeout '%s': got '%s'", mock.expectTimeout, got)
+			}
+		})
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	tests := map[string]struct {
+		phase     string
+		expectErr bool
+	}{
+		"pre-install":  {phase: "pre-install"},
+		"post-install": {phase: "post-install"},
+		"pre-delete":   {phase: "pre-delete", expectErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			hooks := []*unstructured.Unstructured{fakeHook("hook", map[string]string{string(HookAnnotationKeyPhase): mock.phase})}
+			if errs := validateHooks(hooks); mock.expectErr != (len(errs) != 0) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, errs)
+			}
+		})
+	}
+}
+
+func TestSeparateHooks(t *testing.T) {
+	list := []*unstructured.Unstructured{
+		fakeUnstructured("ConfigMap", "one"),
+		fakeHook("hook", map[string]string{string(HookAnnotationKeyPhase): "pre-install"}),
+		fakeUnstructured("ConfigMap", "two"),
+	}
+
+	main, hooks := separateHooks(list)
+	if len(main) != 2 || main[0].GetName() != "one" || main[1].GetName() != "two" {
+		t.Fatalf("expected resources 'one' and 'two': got '%v'", main)
+	}
+	if len(hooks) != 1 || hooks[0].GetName() != "hook" {
+		t.Fatalf("expected hook 'hook': got '%v'", hooks)
+	}
+}
+
+func TestHooksFor(t *testing.T) {
+	hook := func(name, phase, weight string) *unstructured.Unstructured {
+		return fakeHook(name, map[string]string{
+			string(HookAnnotationKeyPhase):  phase,
+			string(HookAnnotationKeyWeight): weight,
+		})
+	}
+	hooks := []*unstructured.Unstructured{
+		hook("pre-b", "pre-install", "1"),
+		hook("post-a", "post-install", "0"),
+		hook("pre-c", "pre-install", "1"),
+		hook("pre-a", "pre-install", "-1"),
+	}
+
+	tests := map[string]struct {
+		phase       HookPhase
+		expectNames []string
+	}{
+		"pre-install hooks by weight retaining the order of same weights": {
+			phase:       HookPhasePreInstall,
+			expectNames: []string{"pre-a", "pre-b", "pre-c"},
+		},
+		"post-install hooks": {
+			phase:       HookPhasePostInstall,
+			expectNames: []string{"post-a"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			var names []string
+			for _, phased := range hooksFor(mock.phase, hooks) {
+				names = append(names, phased.GetName())
+			}
+			if !reflect.DeepEqual(names, mock.expectNames) {
+				t.Fatalf("expected hooks '%v': got '%v'", mock.expectNames, names)
+			}
+		})
+	}
+}
+
+func TestHookCompletionCheckers(t *testing.T) {
+	condition := func(conditionType string) map[string]interface{} {
+		return map[string]interface{}{"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": conditionType, "status": "True"},
+		}}}
+	}
+	phase := func(phase string) map[string]interface{} {
+		return map[string]interface{}{"status": map[string]interface{}{"phase": phase}}
+	}
+
+	tests := map[string]struct {
+		check      HookCompletionChecker
+		live       *unstructured.Unstructured
+		expectDone bool
+		expectErr  bool
+	}{
+		"complete job":    {check: IsJobComplete, live: fakeLive("Job", condition("Complete")), expectDone: true},
+		"failed job":      {check: IsJobComplete, live: fakeLive("Job", condition("Failed")), expectErr: true},
+		"running job":     {check: IsJobComplete, live: fakeLive("Job", nil)},
+		"succeeded pod":   {check: IsPodComplete, live: fakeLive("Pod", phase("Succeeded")), expectDone: true},
+		"failed pod":      {check: IsPodComplete, live: fakeLive("Pod", phase("Failed")), expectErr: true},
+		"running pod":     {check: IsPodComplete, live: fakeLive("Pod", phase("Running"))},
+		"registered jobs": {check: hookCompletionCheckers["Job"], live: fakeLive("Job", condition("Complete")), expectDone: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			done, err := mock.check(mock.live)
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if done != mock.expectDone {
+				t.Fatalf("expected done '%t': got '%t'", mock.expectDone, done)
+			}
+		})
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	hooks := []*unstructured.Unstructured{
+		fakeHook("pre", map[string]string{string(HookAnnotationKeyPhase): "pre-install"}),
+	}
+
+	tests := map[string]struct {
+		ctx      context.Context
+		phase    HookPhase
+		expectOK bool
+	}{
+		"no hooks of the phase": {ctx: context.Background(), phase: HookPhasePostInstall, expectOK: true},
+		"context is done":       {ctx: done, phase: HookPhasePreInstall},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			items, ok := runHooks(mock.ctx, mock.phase, hooks)
+			if ok != mock.expectOK {
+				t.Fatalf("expected ok '%t': got '%t'", mock.expectOK, ok)
+			}
+			if len(items) != 0 {
+				t.Fatalf("expected no hook to be run: got '%+v'", items)
+			}
+		})
+	}
+}
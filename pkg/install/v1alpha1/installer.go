@@ -17,28 +17,67 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
 	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
 	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // Installer abstracts installation
 type Installer interface {
-	Install() (errors []error)
+	Install() (report InstallReport)
+}
+
+// ContextInstaller abstracts installation that stops once the given context
+// is done
+type ContextInstaller interface {
+	InstallContext(ctx context.Context) (report InstallReport)
+}
+
+// Renderer abstracts rendering of the resources that would be installed
+type Renderer interface {
+	Render(format RenderFormat) (rendered []byte, errors []error)
 }
 
+// installErrors is the list of errors that is safe to be added to
+// concurrently
 type installErrors struct {
+	sync.Mutex
 	errors []error
 }
 
 // addError adds an error to error list
+//
+// NOTE:
+//  A nil error is not added
 func (i *installErrors) addError(err error) []error {
-	i.errors = append(i.errors, err)
+	i.Lock()
+	defer i.Unlock()
+
+	if err != nil {
+		i.errors = append(i.errors, err)
+	}
 	return i.errors
 }
 
+// resetErrors clears the error list
+//
+// NOTE:
+//  This lets an installer be re-used across runs
+func (i *installErrors) resetErrors() {
+	i.Lock()
+	defer i.Unlock()
+
+	i.errors = nil
+}
+
 // addErrors adds a list of errors to error list
 func (i *installErrors) addErrors(errs []error) []error {
 	for _, err := range errs {
@@ -48,78 +87,719 @@ func (i *installErrors) addErrors(errs []error) []error {
 	return i.errors
 }
 
+// defaultInstallConcurrency is the number of resources that are applied
+// concurrently if not specified
+const defaultInstallConcurrency = 1
+
+// installConcurrency returns the number of resources that can be applied
+// concurrently
+func installConcurrency() int {
+	concurrency, err := strconv.Atoi(env.Get(string(EnvKeyForInstallConcurrency)))
+	if err != nil || concurrency < 1 {
+		return defaultInstallConcurrency
+	}
+	return concurrency
+}
+
+// installTimeout returns the maximum time an install run may take; zero if
+// install run is not bound by time
+func installTimeout() time.Duration {
+	timeout, err := time.ParseDuration(env.Get(string(EnvKeyForInstallTimeout)))
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// installObjectTimeout returns the maximum time to apply a single resource;
+// zero if applying a resource is not bound by time
+func installObjectTimeout() time.Duration {
+	timeout, err := time.ParseDuration(env.Get(string(EnvKeyForInstallObjectTimeout)))
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
 // simpleInstaller installs artifacts by making use of install config
 //
 // NOTE:
-//  This is an implementation of Installer
+//  This is an implementation of Installer & ContextInstaller
 type simpleInstaller struct {
-	configGetter         ConfigGetterFunc
+	configGetter         ContextConfigGetterFunc
 	artifactLister       VersionArtifactLister
 	transformer          ArtifactToUnstructuredListTransformer
 	unstructuredUpdaters []WithInstallUnstructuredUpdater
+	dependencyResolver   func(ctx context.Context, list []*unstructured.Unstructured) ([][]*unstructured.Unstructured, []error)
+	concurrency          int
+	transactional        bool
+	waitForReadiness     bool
+	waitStrategies       WaitStrategies
+	prune                bool
+	pruneAllowlist       PruneAllowlist
+	history              InstallHistoryRecorder
+	status               InstallStatusRecorder
+	// timeout is the maximum time an install run may take; zero implies no
+	// limit
+	timeout time.Duration
+	// objectTimeout is the maximum time to apply a single resource; zero
+	// implies no limit
+	objectTimeout time.Duration
+	// readinessWaiter waits for the applied resources of a level to be ready
+	readinessWaiter func(ctx context.Context, list []*unstructured.Unstructured, strategies WaitStrategies) []ReadinessReportItem
+	// applier applies a single resource in kubernetes cluster
+	applier func(ctx context.Context, unstruct *unstructured.Unstructured) (k8s.ApplyAction, error)
+	// config is the install config that was last used by this installer
+	config *InstallConfig
+	// hooks are the hook artifacts derived from the install config that was
+	// last used by this installer
+	hooks []*unstructured.Unstructured
+	// skipped are the artifacts that were not selected by the install config
+	// that was last used by this installer
+	skipped []SkipReportItem
 	installErrors
 }
 
-// Install the resources specified in the install config
+// unstructuredList returns the final list of unstructured instances that are
+// derived from the install config
 //
 // NOTE:
-//  This is an implementation of Installer interface
-func (i *simpleInstaller) Install() []error {
-	var (
-		allUnstructured []*unstructured.Unstructured
-	)
-
+//  Errors if any are accumulated in the installer's error list. The install
+// config is fetched within the given context.
+func (i *simpleInstaller) unstructuredList(ctx context.Context) (allUnstructured []*unstructured.Unstructured) {
 	if i.configGetter == nil {
-		return i.addError(fmt.Errorf("nil config getter: simple installer failed"))
+		i.addError(fmt.Errorf("nil config getter: simple installer failed"))
+		return
 	}
 
-	config, err := i.configGetter(env.Get(string(EnvKeyForInstallConfigName)))
+	// state of the previous run is not carried forward
+	i.config = nil
+	i.skipped = nil
+
+	config, err := i.configGetter(ctx, env.Get(string(EnvKeyForInstallConfigName)))
 	if err != nil {
-		return i.addError(errors.Wrap(err, "simple installer failed"))
+		i.addError(errors.Wrap(err, "simple installer failed"))
+		return
 	}
+	i.config = config
 
 	for _, install := range config.Spec.Install {
-		list, err := i.artifactLister(install.Version)
+		allUnstructured = append(allUnstructured, i.installUnstructuredList(install)...)
+	}
+
+	return
+}
+
+// installUnstructuredList returns the list of unstructured instances that
+// are derived from the given install specs
+//
+// NOTE:
+//  Errors if any are accumulated in the installer's error list. Artifacts
+// that are not selected by the install specs are accumulated in the
+// installer's skipped list.
+func (i *simpleInstaller) installUnstructuredList(install Install) (allUnstructured []*unstructured.Unstructured) {
+	list, err := i.artifactLister(install.Version)
+	if err != nil {
+		i.addError(errors.Wrapf(err, "simple installer failed to list artifacts for version '%s'", install.Version))
+		return
+	}
+
+	err = validateMergeStrategy(install.SetOptions.MergeStrategy)
+	if err != nil {
+		i.addError(errors.Wrapf(err, "simple installer failed to set options for version '%s'", install.Version))
+		return
+	}
+
+	errs := validatePatches(install.SetOptions.Patches)
+	if len(errs) != 0 {
+		for _, err := range errs {
+			i.addError(errors.Wrapf(err, "simple installer failed to set options for version '%s'", install.Version))
+		}
+		return
+	}
+
+	// override the default config of cas templates before the artifacts are
+	// selected
+	list, errs = UpdateDefaultConfigArtifactListFor(install)(list)
+	if len(errs) != 0 {
+		i.addErrors(errs)
+		return
+	}
+
+	// filter the artifacts before they are transformed
+	list, skipped, errs := SelectArtifactListFor(install)(list)
+	if len(errs) != 0 {
+		i.addErrors(errs)
+		return
+	}
+	i.skipped = append(i.skipped, skipped...)
+
+	// transform list of artifacts to list of unstructured instances
+	unstructs, errs := i.transformer(list)
+	if len(errs) != 0 {
+		i.addErrors(errs)
+	}
+
+	// override the unstructured instances from install set options
+	//
+	// NOTE:
+	//  An updater drops the instance it fails to update
+	for _, unstruct := range unstructs {
+		installUpdaters := WithInstallUnstructuredUpdaterList(install, i.unstructuredUpdaters)
+		finalUpdater := k8s.UnstructuredUpdater(installUpdaters)
+		if updated := finalUpdater(unstruct); updated != nil {
+			allUnstructured = append(allUnstructured, updated)
+		}
+	}
+
+	return
+}
+
+// unstructuredLevels returns the unstructured instances derived from the
+// install config grouped into levels in the order of their dependencies
+//
+// NOTE:
+//  All the instances are returned as a single level if their dependencies
+// could not be resolved. Errors if any are accumulated in the installer's
+// error list.
+//
+// NOTE:
+//  Hooks are not part of the levels; they are set against the installer
+func (i *simpleInstaller) unstructuredLevels(ctx context.Context) (levels [][]*unstructured.Unstructured, errs []error) {
+	return i.unstructuredLevelsWith(ctx, i.dependencyResolver)
+}
+
+// unstructuredLevelsWith returns the unstructured instances derived from the
+// install config grouped into levels by the given dependency resolver
+func (i *simpleInstaller) unstructuredLevelsWith(
+	ctx context.Context,
+	resolve func(ctx context.Context, list []*unstructured.Unstructured) ([][]*unstructured.Unstructured, []error),
+) (levels [][]*unstructured.Unstructured, errs []error) {
+	allUnstructured, hooks := separateHooks(i.unstructuredList(ctx))
+	i.hooks = hooks
+	if errs = validateHooks(hooks); len(errs) != 0 {
+		i.addErrors(errs)
+		return [][]*unstructured.Unstructured{allUnstructured}, errs
+	}
+
+	if resolve == nil {
+		return [][]*unstructured.Unstructured{allUnstructured}, nil
+	}
+
+	levels, errs = resolve(ctx, allUnstructured)
+	if len(errs) != 0 {
+		i.addErrors(errs)
+		return [][]*unstructured.Unstructured{allUnstructured}, errs
+	}
+
+	return
+}
+
+// resolveDependencies orders the given unstructured instances into levels
+// based on their dependencies
+//
+// NOTE:
+//  A run task that is not amongst the given instances is considered available
+// if it was not selected by the install config or if it is already available
+// in kubernetes cluster. The cluster is looked up within the given context.
+func (i *simpleInstaller) resolveDependencies(ctx context.Context, list []*unstructured.Unstructured) ([][]*unstructured.Unstructured, []error) {
+	return ResolveUnstructuredDependenciesWith(
+		SkippedReferenceChecker(i.skipped),
+		LiveReferenceChecker(func(namespace string) k8s.ConfigMapGetter {
+			return k8s.NewConfigMapGetterWithContext(ctx, namespace)
+		}),
+	)(list)
+}
+
+// resolveSkippedDependencies orders the given unstructured instances into
+// levels based on their dependencies without looking up kubernetes cluster
+//
+// NOTE:
+//  A run task that is not amongst the given instances is considered available
+// only if it was not selected by the install config
+func (i *simpleInstaller) resolveSkippedDependencies(ctx context.Context, list []*unstructured.Unstructured) ([][]*unstructured.Unstructured, []error) {
+	return ResolveUnstructuredDependenciesWith(SkippedReferenceChecker(i.skipped))(list)
+}
+
+// orderedUnstructuredList returns the unstructured instances derived from the
+// install config in the order they will be installed
+//
+// NOTE:
+//  Hooks are placed as per the phase they run in
+func (i *simpleInstaller) orderedUnstructuredList(ctx context.Context) []*unstructured.Unstructured {
+	levels, _ := i.unstructuredLevels(ctx)
+	return i.orderedWithHooks(levels)
+}
+
+// orderedWithHooks returns the unstructured instances of the given levels
+// placed in between the hooks of the phases they run in
+func (i *simpleInstaller) orderedWithHooks(levels [][]*unstructured.Unstructured) (ordered []*unstructured.Unstructured) {
+	ordered = append(ordered, hooksFor(HookPhasePreInstall, i.hooks)...)
+	for _, level := range levels {
+		ordered = append(ordered, level...)
+	}
+	ordered = append(ordered, hooksFor(HookPhasePostInstall, i.hooks)...)
+	return
+}
+
+// orderedMainUnstructuredList returns the unstructured instances derived
+// from the install config in the order they will be installed excluding the
+// hooks
+func (i *simpleInstaller) orderedMainUnstructuredList(ctx context.Context) (ordered []*unstructured.Unstructured) {
+	levels, _ := i.unstructuredLevels(ctx)
+	for _, level := range levels {
+		ordered = append(ordered, level...)
+	}
+	return
+}
+
+// Install the resources specified in the install config
+//
+// NOTE:
+//  Nothing is installed if the dependencies amongst the resources could not
+// be resolved or if a pre-install hook fails. Post-install hooks are run only
+// if install succeeds.
+//
+// NOTE:
+//  The progress as well as the outcome of install is recorded against the
+// install config if the installer has a status recorder
+//
+// NOTE:
+//  The outcome of install is recorded in the install metrics
+//
+// NOTE:
+//  This is an implementation of Installer interface
+func (i *simpleInstaller) Install() InstallReport {
+	return i.InstallContext(context.Background())
+}
+
+// InstallContext installs the resources specified in the install config till
+// the given context is done
+//
+// NOTE:
+//  Once the context is done the resources in flight fail while the remaining
+// resources are reported as not attempted. The outcome is recorded even if
+// the context is done.
+//
+// NOTE:
+//  This is an implementation of ContextInstaller interface
+func (i *simpleInstaller) InstallContext(ctx context.Context) InstallReport {
+	if i.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.timeout)
+		defer cancel()
+	}
+
+	i.resetErrors()
+
+	if i.status != nil {
+		err := i.status.Progressing(ctx)
 		if err != nil {
-			i.addError(errors.Wrapf(err, "simple installer failed to list artifacts for version '%s'", install.Version))
+			i.addError(errors.Wrap(err, "simple installer failed to record install progress"))
+		}
+	}
+
+	// every install run is recorded irrespective of how far it got
+	report := i.recordStatus(ctx, i.recordHistory(ctx, i.install(ctx)))
+	observeInstall(report, time.Now())
+	return report
+}
+
+// install applies the resources specified in the install config till the
+// given context is done
+//
+// NOTE:
+//  If readiness is waited upon, a level is applied only after the resources
+// of its previous levels are ready. The levels after a level that is not
+// ready are not attempted.
+func (i *simpleInstaller) install(ctx context.Context) InstallReport {
+	levels, errs := i.unstructuredLevels(ctx)
+	if len(errs) != 0 {
+		report := newInstallReport(nil, i.errors)
+		report.Skipped = i.skipped
+		return report
+	}
+
+	// readiness is waited upon if the install config specifies how to wait
+	var waitOptions []WaitOptions
+	if i.config != nil {
+		waitOptions = i.config.Spec.Wait
+	}
+	waitForReadiness := i.waitForReadiness || len(waitOptions) != 0
+	strategies, err := i.waitStrategies.WithOptions(waitOptions, readinessTimeout())
+	if err != nil {
+		i.addError(errors.Wrap(err, "simple installer failed"))
+		report := newInstallReport(nil, i.errors)
+		report.Skipped = i.skipped
+		return report
+	}
+
+	hookItems, ok := runHooks(ctx, HookPhasePreInstall, i.hooks)
+	if !ok {
+		report := newInstallReport(i.notAttempted(ctx, nil, levels), i.errors)
+		report.Hooks = hookItems
+		report.Skipped = i.skipped
+		return report
+	}
+
+	// a level is applied only after all of its previous levels are applied
+	// & are ready if readiness is waited upon
+	var items []InstallReportItem
+	var journal []transactionEntry
+	var rollback []RollbackReportItem
+	var readiness []ReadinessReportItem
+	var attempted int
+	for _, level := range levels {
+		if ctx.Err() != nil {
+			break
+		}
+
+		levelItems, entries := i.applyLevel(ctx, level)
+		items = append(items, levelItems...)
+		journal = append(journal, entries...)
+		attempted++
+
+		if i.transactional && (hasFailedItems(levelItems) || ctx.Err() != nil) {
+			rollback = rollbackTransaction(journal)
+			break
+		}
+
+		if !waitForReadiness || ctx.Err() != nil {
 			continue
 		}
 
-		// transform list of artifacts to list of unstructured instances
-		unstructs, errs := i.transformer(list)
-		if len(errs) != 0 {
-			i.addErrors(errs)
+		var applied []*unstructured.Unstructured
+		for idx, item := range levelItems {
+			if item.Action != InstallActionFailed {
+				applied = append(applied, level[idx])
+			}
+		}
+
+		levelReadiness := i.readinessWaiter(ctx, applied, strategies)
+		readiness = append(readiness, levelReadiness...)
+		if !isReady(levelReadiness) {
+			// the next levels may depend on the resources that are not ready
+			if i.transactional {
+				rollback = rollbackTransaction(journal)
+			}
+			break
 		}
+	}
+
+	report := newInstallReport(i.notAttempted(ctx, items, levels[attempted:]), i.errors)
+	report.Rollback = rollback
+	report.Hooks = hookItems
+	report.Skipped = i.skipped
+	report.Readiness = readiness
+	if i.prune && report.IsSuccess() {
+		report = i.pruneAfterInstall(ctx, report, levels)
+	}
+	if report.IsSuccess() {
+		postHookItems, _ := runHooks(ctx, HookPhasePostInstall, i.hooks)
+		report.Hooks = append(report.Hooks, postHookItems...)
+	}
+
+	return report
+}
+
+// WithWaitStrategy sets the wait strategy that is used to determine the
+// readiness of the installed resources of the given kind
+func (i *simpleInstaller) WithWaitStrategy(kind string, strategy WaitStrategy) *simpleInstaller {
+	if i.waitStrategies == nil {
+		i.waitStrategies = WaitStrategies{}
+	}
+	i.waitStrategies[kind] = strategy
+	return i
+}
+
+// notAttempted returns the given report items along with the resources of
+// the given levels marked as not attempted
+//
+// NOTE:
+//  The levels may not be attempted due to a failed hook, a rolled back
+// transaction or an interruption
+//
+// NOTE:
+//  The reason of interruption i.e. the error of the given context, if any, is
+// accumulated in the installer's error list
+func (i *simpleInstaller) notAttempted(ctx context.Context, items []InstallReportItem, levels [][]*unstructured.Unstructured) []InstallReportItem {
+	for _, level := range levels {
+		for _, unstruct := range level {
+			items = append(items, notAttemptedInstallReportItem(unstruct))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		i.addError(errors.Wrap(err, "simple installer was interrupted"))
+	}
+	return items
+}
 
-		// override the unstructured instances from install set options
-		for _, unstruct := range unstructs {
-			installUpdaters := WithInstallUnstructuredUpdaterList(install, i.unstructuredUpdaters)
-			finalUpdater := k8s.UnstructuredUpdater(installUpdaters)
-			allUnstructured = append(allUnstructured, finalUpdater(unstruct))
+// hasFailedItems returns true if any of the given report items has failed
+func hasFailedItems(items []InstallReportItem) bool {
+	for _, item := range items {
+		if item.Action == InstallActionFailed {
+			return true
 		}
 	}
+	return false
+}
+
+// pruneAfterInstall deletes the resources that are owned by the install
+// config but are no longer amongst the given installed levels
+//
+// NOTE:
+//  Failure to prune is added to the report
+//
+// NOTE:
+//  Pruning is skipped if the name of the install config is not known e.g.
+// when the install config is read from a file since the installed resources
+// are not labelled with their owner
+func (i *simpleInstaller) pruneAfterInstall(ctx context.Context, report InstallReport, levels [][]*unstructured.Unstructured) InstallReport {
+	if len(pruneConfigName()) == 0 {
+		glog.Warningf("missing install config name: skipping prune: set %s to prune", EnvKeyForInstallConfigName)
+		return report
+	}
 
-	for _, unstruct := range allUnstructured {
-		apply := k8s.NewResourceApplier(GroupVersionResourceFromGVK(unstruct), unstruct.GetNamespace())
-		_, err := apply(unstruct)
+	desired := append([]*unstructured.Unstructured{}, i.hooks...)
+	for _, level := range levels {
+		desired = append(desired, level...)
+	}
+
+	pruned, err := i.pruneUnlisted(ctx, desired, false)
+	if err != nil {
+		err = errors.Wrap(err, "simple installer failed to prune")
 		i.addError(err)
+		report.Errors = append(report.Errors, err.Error())
 	}
+	report.Pruned = pruned
 
-	return i.errors
+	return report
+}
+
+// recordStatus records the outcome of install corresponding to the given
+// report against the install config
+//
+// NOTE:
+//  Failure to record the status is added to the report
+func (i *simpleInstaller) recordStatus(ctx context.Context, report InstallReport) InstallReport {
+	if i.status == nil {
+		return report
+	}
+
+	err := i.status.Record(ctx, i.config, report)
+	if err != nil {
+		err = errors.Wrap(err, "simple installer failed to record install status")
+		i.addError(err)
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	return report
+}
+
+// recordHistory records the install run corresponding to the given report
+// in the install history
+//
+// NOTE:
+//  Failure to record the install run is added to the report
+func (i *simpleInstaller) recordHistory(ctx context.Context, report InstallReport) InstallReport {
+	if i.history == nil {
+		return report
+	}
+
+	entry := NewInstallHistoryEntry(env.Get(string(EnvKeyForInstallConfigName)), i.config, report)
+	err := i.history.Record(ctx, entry)
+	if err != nil {
+		err = errors.Wrap(err, "simple installer failed to record install history")
+		i.addError(err)
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	return report
+}
+
+// applyLevel applies the given unstructured instances via a pool of workers
+//
+// NOTE:
+//  The returned report items as well as transaction entries are in the same
+// order as the given unstructured instances irrespective of the order of
+// completion
+func (i *simpleInstaller) applyLevel(ctx context.Context, level []*unstructured.Unstructured) ([]InstallReportItem, []transactionEntry) {
+	items := make([]InstallReportItem, len(level))
+	entries := make([]transactionEntry, len(level))
+
+	workers := i.concurrency
+	if workers < 1 {
+		workers = defaultInstallConcurrency
+	}
+	if workers > len(level) {
+		workers = len(level)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				// resources are not applied once the install run is
+				// interrupted
+				if ctx.Err() != nil {
+					items[idx] = notAttemptedInstallReportItem(level[idx])
+					entries[idx] = transactionEntry{desired: level[idx], action: InstallActionNotAttempted}
+					continue
+				}
+				items[idx], entries[idx] = i.applyUnstructured(ctx, level[idx])
+			}
+		}()
+	}
+
+	for idx := range level {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	return items, entries
+}
+
+// applyUnstructured applies the given unstructured instance and returns the
+// corresponding report item along with the transaction entry
+//
+// NOTE:
+//  The resource's prior state is captured in the transaction entry only if
+// the install is transactional
+//
+// NOTE:
+//  Applying the resource fails if it takes longer than the object timeout
+func (i *simpleInstaller) applyUnstructured(ctx context.Context, unstruct *unstructured.Unstructured) (InstallReportItem, transactionEntry) {
+	item := newInstallReportItem(unstruct)
+	entry := transactionEntry{desired: unstruct}
+	start := time.Now()
+
+	if i.objectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.objectTimeout)
+		defer cancel()
+	}
+
+	if i.transactional {
+		snapshot, err := snapshotUnstructured(ctx, unstruct)
+		if err != nil {
+			item.Duration = time.Since(start)
+			item.Action = InstallActionFailed
+			item.Error = err.Error()
+			return item, entry
+		}
+		entry.snapshot = snapshot
+	}
+
+	action, err := i.applier(ctx, unstruct)
+
+	item.Duration = time.Since(start)
+	item.Action = InstallAction(action)
+	if err != nil {
+		item.Action = InstallActionFailed
+		item.Error = err.Error()
+	}
+	entry.action = item.Action
+
+	return item, entry
+}
+
+// applyResource creates or updates the given unstructured instance in
+// kubernetes cluster within the given context
+func applyResource(ctx context.Context, unstruct *unstructured.Unstructured) (k8s.ApplyAction, error) {
+	apply := k8s.NewResourceActionApplierWithContext(ctx, GroupVersionResourceFromGVK(unstruct), unstruct.GetNamespace())
+	_, action, err := apply(unstruct)
+	return action, err
+}
+
+// Render returns the resources specified in the install config in the
+// provided format without installing them
+//
+// NOTE:
+//  Rendering does not need kubernetes cluster unless the install config is
+// fetched from it. Hence, the run tasks that are neither rendered nor skipped
+// by the install config are reported as missing instead of being looked up in
+// the cluster.
+//
+// NOTE:
+//  This is an implementation of Renderer interface
+func (i *simpleInstaller) Render(format RenderFormat) ([]byte, []error) {
+	levels, _ := i.unstructuredLevelsWith(context.Background(), i.resolveSkippedDependencies)
+	allUnstructured := i.orderedWithHooks(levels)
+
+	render, err := UnstructuredListRendererFor(format)
+	if err != nil {
+		return nil, i.addError(errors.Wrap(err, "simple installer failed to render"))
+	}
+
+	rendered, err := render(allUnstructured)
+	if err != nil {
+		return nil, i.addError(errors.Wrap(err, "simple installer failed to render"))
+	}
+
+	return rendered, i.errors
 }
 
 // SimpleInstaller returns a new instance of simpleInstaller
+//
+// NOTE:
+//  Every install run is recorded in the install history placed next to the
+// install config. The state of install is recorded as events & annotations of
+// the install config.
+//
+// NOTE:
+//  The install config is fetched within the context of the install run
 func SimpleInstaller() *simpleInstaller {
-	cmGetter := k8s.NewConfigMapGetter(env.Get(string(EnvKeyForInstallConfigNamespace)))
-
-	return &simpleInstaller{
-		configGetter:   WithConfigMapConfigGetter(cmGetter),
-		artifactLister: ListArtifactsByVersion,
-		transformer:    TransformArtifactToUnstructuredList,
-		unstructuredUpdaters: []WithInstallUnstructuredUpdater{
-			updateUnstructuredNamespace,
-			updateUnstructuredLabels,
-			updateUnstructuredAnnotations,
-		},
+	namespace := env.Get(string(EnvKeyForInstallConfigNamespace))
+
+	installer := NewContextSimpleInstaller(WithContextConfigMapConfigGetter(namespace))
+	installer.history = NewConfigMapInstallHistory(namespace, env.Get(string(EnvKeyForInstallConfigName)))
+	installer.status = NewConfigMapInstallStatus(namespace, env.Get(string(EnvKeyForInstallConfigName)))
+	return installer
+}
+
+// NewSimpleInstaller returns a new instance of simpleInstaller that makes use
+// of the provided config getter to fetch the install config
+func NewSimpleInstaller(configGetter ConfigGetterFunc) *simpleInstaller {
+	return NewContextSimpleInstaller(IgnoreContextConfigGetter(configGetter))
+}
+
+// NewContextSimpleInstaller returns a new instance of simpleInstaller that
+// makes use of the provided config getter to fetch the install config within
+// the context of the install run
+func NewContextSimpleInstaller(configGetter ContextConfigGetterFunc) *simpleInstaller {
+	installer := &simpleInstaller{
+		configGetter:     configGetter,
+		artifactLister:   ListArtifactsByVersion,
+		transformer:      TransformArtifactToUnstructuredList,
+		concurrency:      installConcurrency(),
+		transactional:    isTransactionalInstall(),
+		waitForReadiness: isWaitForReadiness(),
+		waitStrategies:   DefaultWaitStrategies(readinessTimeout()),
+		readinessWaiter:  WaitForReadinessWithContext,
+		applier:          applyResource,
+		prune:            isPruneEnabled(),
+		pruneAllowlist:   DefaultPruneAllowlist(),
+		timeout:          installTimeout(),
+		objectTimeout:    installObjectTimeout(),
+	}
+
+	installer.dependencyResolver = installer.resolveDependencies
+	installer.unstructuredUpdaters = []WithInstallUnstructuredUpdater{
+		updateUnstructuredNamespace,
+		updateUnstructuredLabels,
+		updateUnstructuredAnnotations,
+		updateUnstructuredSourceArtifact,
+		// patches may update anything set so far
+		installer.updateUnstructuredPatches,
+		// ownership can not be patched
+		updateUnstructuredOwnership,
+		// digest is computed after all the other updates
+		updateUnstructuredDigest,
 	}
+	return installer
 }
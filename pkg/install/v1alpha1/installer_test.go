@@ -0,0 +1,318 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func fakeUnstructured(kind, name string) *unstructured.Unstructured {
+	unstruct := &unstructured.Unstructured{}
+	unstruct.SetAPIVersion("v1")
+	unstruct.SetKind(kind)
+	unstruct.SetName(name)
+	unstruct.SetNamespace("openebs")
+	return unstruct
+}
+
+func TestApplyLevelWhenInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	level := []*unstructured.Unstructured{fakeUnstructured("ConfigMap", "one"), fakeUnstructured("Service", "two")}
+	i := &simpleInstaller{concurrency: 2}
+
+	items, entries := i.applyLevel(ctx, level)
+	for idx, item := range items {
+		if item.Action != InstallActionNotAttempted || item.Name != level[idx].GetName() {
+			t.Fatalf("expected '%s' to be '%s': got '%s' for '%s'", level[idx].GetName(), InstallActionNotAttempted, item.Action, item.Name)
+		}
+		if entries[idx].action != InstallActionNotAttempted {
+			t.Fatalf("expected transaction entry '%s' to be '%s': got '%s'", level[idx].GetName(), InstallActionNotAttempted, entries[idx].action)
+		}
+	}
+}
+
+func TestInstallConcurrency(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected int
+	}{
+		"not set":  {value: "", expected: defaultInstallConcurrency},
+		"valid":    {value: "4", expected: 4},
+		"zero":     {value: "0", expected: defaultInstallConcurrency},
+		"negative": {value: "-2", expected: defaultInstallConcurrency},
+		"invalid":  {value: "many", expected: defaultInstallConcurrency},
+	}
+
+	defer os.Unsetenv(string(EnvKeyForInstallConcurrency))
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(EnvKeyForInstallConcurrency), mock.value)
+			if got := installConcurrency(); got != mock.expected {
+				t.Fatalf("expected concurrency '%d': got '%d'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestApplyLevelRetainsOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var level []*unstructured.Unstructured
+	for idx := 0; idx < 20; idx++ {
+		level = append(level, fakeUnstructured("ConfigMap", fmt.Sprintf("cm-%d", idx)))
+	}
+
+	tests := map[string]struct {
+		concurrency int
+		level       []*unstructured.Unstructured
+	}{
+		"empty level":                  {concurrency: 4},
+		"unset concurrency":            {concurrency: 0, level: level},
+		"single worker":                {concurrency: 1, level: level},
+		"fewer workers than resources": {concurrency: 3, level: level},
+		"more workers than resources":  {concurrency: 50, level: level},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			i := &simpleInstaller{concurrency: mock.concurrency}
+			items, entries := i.applyLevel(ctx, mock.level)
+			if len(items) != len(mock.level) || len(entries) != len(mock.level) {
+				t.Fatalf("expected '%d' items and entries: got '%d' items and '%d' entries", len(mock.level), len(items), len(entries))
+			}
+			for idx := range mock.level {
+				if items[idx].Name != mock.level[idx].GetName() || entries[idx].desired != mock.level[idx] {
+					t.Fatalf("expected '%s' at '%d': got item '%s'", mock.level[idx].GetName(), idx, items[idx].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestNotAttempted(t *testing.T) {
+	levels := [][]*unstructured.Unstructured{
+		{fakeUnstructured("Namespace", "openebs")},
+		{fakeUnstructured("ConfigMap", "one"), fakeUnstructured("Service", "two")},
+	}
+	applied := []InstallReportItem{{Kind: "ServiceAccount", Name: "zero", Action: InstallActionCreated}}
+
+	tests := map[string]struct {
+		levels      [][]*unstructured.Unstructured
+		cancel      bool
+		expectItems int
+		expectError bool
+	}{
+		"all levels attempted":   {expectItems: 1},
+		"levels not attempted":   {levels: levels, expectItems: 4},
+		"interrupted":            {levels: levels, cancel: true, expectItems: 4, expectError: true},
+		"interrupted at the end": {cancel: true, expectItems: 1, expectError: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if mock.cancel {
+				cancel()
+			}
+
+			i := &simpleInstaller{}
+			report := newInstallReport(i.notAttempted(ctx, applied, mock.levels), i.errors)
+			if len(report.Items) != mock.expectItems {
+				t.Fatalf("expected '%d' items: got '%d'", mock.expectItems, len(report.Items))
+			}
+			if mock.expectError != (len(report.Errors) != 0) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectError, report.Errors)
+			}
+			if report.Summary.NotAttempted != mock.expectItems-1 {
+				t.Fatalf("expected '%d' not attempted: got '%d'", mock.expectItems-1, report.Summary.NotAttempted)
+			}
+			expectSuccess := mock.expectItems == 1 && !mock.expectError
+			if report.IsSuccess() != expectSuccess {
+				t.Fatalf("expected success '%t': got '%t'", expectSuccess, report.IsSuccess())
+			}
+			if mock.expectItems == 4 && !strings.Contains(report.Table(), "3 not attempted") {
+				t.Fatalf("expected not attempted count in table: got '%s'", report.Table())
+			}
+		})
+	}
+}
+
+func TestInstallContextWhenInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+		return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+	})
+	i.prune = false
+	i.waitForReadiness = false
+
+	report := i.InstallContext(ctx)
+	if report.Summary.Total == 0 || report.Summary.NotAttempted != report.Summary.Total {
+		t.Fatalf("expected all resources to be not attempted: got summary '%+v'", report.Summary)
+	}
+	if report.IsSuccess() {
+		t.Fatalf("expected interrupted install to fail: got success")
+	}
+}
+
+func TestInstallWhenTransactionIsRolledBack(t *testing.T) {
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+		return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+	})
+	i.transactional = true
+	i.prune = false
+	i.waitForReadiness = false
+
+	// resources fail to apply in the absence of a kubernetes cluster
+	report := i.install(context.Background())
+	if len(report.Rollback) == 0 && report.Summary.Failed == 0 {
+		t.Skip("expected resources to fail in the absence of a kubernetes cluster")
+	}
+	if report.Summary.NotAttempted == 0 {
+		t.Fatalf("expected resources of the remaining levels to be not attempted: got summary '%+v'", report.Summary)
+	}
+	if report.Summary.Total != report.Summary.Failed+report.Summary.NotAttempted+report.Summary.Created+report.Summary.Updated+report.Summary.Unchanged {
+		t.Fatalf("expected every resource to be reported: got summary '%+v'", report.Summary)
+	}
+	for _, err := range report.Errors {
+		if strings.Contains(err, "interrupted") {
+			t.Fatalf("expected rolled back install not to be interrupted: got '%s'", err)
+		}
+	}
+}
+
+func TestInstallContextWhenReused(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+		return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+	})
+	i.prune = false
+	i.waitForReadiness = false
+
+	first := i.InstallContext(ctx)
+	second := i.InstallContext(ctx)
+	if len(second.Errors) != len(first.Errors) {
+		t.Fatalf("expected errors of a previous run not to be reported: got '%v'", second.Errors)
+	}
+}
+
+func TestInstallWaitsForEachLevel(t *testing.T) {
+	tests := map[string]struct {
+		notReady      string
+		wait          []WaitOptions
+		expectApplied []string
+		expectWaited  [][]string
+		expectSuccess bool
+		// expectNotAttempted is the number of resources that are not
+		// attempted
+		expectNotAttempted int
+	}{
+		"all levels are ready": {
+			expectApplied: []string{"openebs", "cstor-pool", "maya"},
+			expectWaited:  [][]string{{"openebs"}, {"cstor-pool"}, {"maya"}},
+			expectSuccess: true,
+		},
+		"first level is not ready": {
+			notReady:           "openebs",
+			expectApplied:      []string{"openebs"},
+			expectWaited:       [][]string{{"openebs"}},
+			expectNotAttempted: 2,
+		},
+		"second level is not ready": {
+			notReady:           "cstor-pool",
+			expectApplied:      []string{"openebs", "cstor-pool"},
+			expectWaited:       [][]string{{"openebs"}, {"cstor-pool"}},
+			expectNotAttempted: 1,
+		},
+		"invalid wait options": {
+			wait: []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase"}},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			levels := [][]*unstructured.Unstructured{
+				{fakeUnstructured("Namespace", "openebs")},
+				{fakeUnstructured("CStorPool", "cstor-pool")},
+				{fakeUnstructured("Deployment", "maya")},
+			}
+
+			i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+				return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}, Wait: mock.wait}}, nil
+			})
+			i.prune = false
+			i.transactional = false
+			i.waitForReadiness = true
+			i.artifactLister = func(version string) (ArtifactList, error) {
+				return ArtifactList{}, nil
+			}
+			i.dependencyResolver = func(ctx context.Context, list []*unstructured.Unstructured) ([][]*unstructured.Unstructured, []error) {
+				return levels, nil
+			}
+
+			var applied []string
+			var mu sync.Mutex
+			i.applier = func(ctx context.Context, unstruct *unstructured.Unstructured) (k8s.ApplyAction, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				applied = append(applied, unstruct.GetName())
+				return k8s.ApplyActionCreated, nil
+			}
+
+			var waited [][]string
+			i.readinessWaiter = func(ctx context.Context, list []*unstructured.Unstructured, strategies WaitStrategies) (items []ReadinessReportItem) {
+				var names []string
+				for _, unstruct := range list {
+					names = append(names, unstruct.GetName())
+					items = append(items, ReadinessReportItem{Kind: unstruct.GetKind(), Name: unstruct.GetName(), Ready: unstruct.GetName() != mock.notReady})
+				}
+				waited = append(waited, names)
+				return
+			}
+
+			report := i.install(context.Background())
+			if report.IsSuccess() != mock.expectSuccess {
+				t.Fatalf("expected success '%t': got report '%+v'", mock.expectSuccess, report)
+			}
+			if !reflect.DeepEqual(applied, mock.expectApplied) {
+				t.Fatalf("expected applied '%v': got '%v'", mock.expectApplied, applied)
+			}
+			if !reflect.DeepEqual(waited, mock.expectWaited) {
+				t.Fatalf("expected waited '%v': got '%v'", mock.expectWaited, waited)
+			}
+			if report.Summary.NotAttempted != mock.expectNotAttempted {
+				t.Fatalf("expected '%d' not attempted: got summary '%+v'", mock.expectNotAttempted, report.Summary)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ package v1alpha1
 func JivaVolumeArtifactsFor070() (list ArtifactList) {
 	list.Items = append(list.Items, jivaVolumeCASTemplatesFor070()...)
 	list.Items = append(list.Items, jivaVolumeRunTasksFor070()...)
+	list.Items = WithCASEngineListUpdater(CASEngineJiva, list.Items)
 	return
 }
 
@@ -0,0 +1,338 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// defaultLeaseDuration is the duration a non leader waits after the last
+	// observed renewal before it can take over the leadership
+	defaultLeaseDuration = 15 * time.Second
+	// defaultRenewDeadline is the duration the leader retries renewing its
+	// leadership before it gives up the leadership
+	defaultRenewDeadline = 10 * time.Second
+	// defaultRetryPeriod is the interval between attempts to acquire or renew
+	// the leadership
+	defaultRetryPeriod = 2 * time.Second
+)
+
+// isLeaderElectionEnabled returns true if the install controller needs to be
+// the elected leader before it can reconcile
+func isLeaderElectionEnabled() bool {
+	elect, _ := strconv.ParseBool(env.Get(string(EnvKeyForInstallLeaderElection)))
+	return elect
+}
+
+// leaderLockName returns the name of the config map used as the leader
+// election lock of the given install config
+func leaderLockName(configName string) string {
+	return configName + "-leader"
+}
+
+// leaderIdentity returns the identity of this candidate
+//
+// NOTE:
+//  Host name is the pod name when run in kubernetes cluster
+func leaderIdentity() (string, error) {
+	identity := strings.TrimSpace(env.Get(string(EnvKeyForInstallLeaderIdentity)))
+	if len(identity) != 0 {
+		return identity, nil
+	}
+
+	identity, err := os.Hostname()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get leader identity")
+	}
+	return identity, nil
+}
+
+// LeaderElector abstracts running a function only while being the elected
+// leader amongst a set of candidates
+type LeaderElector interface {
+	// Run blocks till the given stop channel is closed or the leadership is
+	// lost; the given function is run once the leadership is acquired & is
+	// expected to return once its stop channel is closed
+	Run(stopCh <-chan struct{}, lead func(stopCh <-chan struct{})) error
+}
+
+// configMapLeaderElector elects a leader via the leader election of
+// client-go by making use of a config map as the lock
+//
+// NOTE:
+//  This is an implementation of LeaderElector
+type configMapLeaderElector struct {
+	namespace     string
+	lockName      string
+	identity      string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+	client        corev1client.ConfigMapsGetter
+	recorder      record.EventRecorder
+}
+
+// NewConfigMapLeaderElector returns a new instance of leader elector that
+// makes use of a config map keyed by the given install config name as the
+// lock
+func NewConfigMapLeaderElector(namespace, configName, identity string, client corev1client.ConfigMapsGetter) *configMapLeaderElector {
+	return &configMapLeaderElector{
+		namespace:     namespace,
+		lockName:      leaderLockName(configName),
+		identity:      identity,
+		leaseDuration: defaultLeaseDuration,
+		renewDeadline: defaultRenewDeadline,
+		retryPeriod:   defaultRetryPeriod,
+		client:        client,
+		recorder:      installEventRecorder,
+	}
+}
+
+// Run acquires the leadership, runs the given function & keeps renewing the
+// leadership till the given stop channel is closed
+//
+// NOTE:
+//  Leadership is released on stop once the given function returns; another
+// candidate takes over at once instead of waiting for the lease to expire
+//
+// NOTE:
+//  The lock record is the one used by leader election of client-go. However,
+// the election is run here since leader election of client-go can neither be
+// stopped while it attempts to acquire the leadership nor release the
+// leadership on stop.
+//
+// NOTE:
+//  This is an implementation of LeaderElector interface
+func (e *configMapLeaderElector) Run(stopCh <-chan struct{}, lead func(stopCh <-chan struct{})) error {
+	if e.client == nil {
+		return fmt.Errorf("nil config map client: failed to run leader election for '%s'", e.lockName)
+	}
+
+	if len(strings.TrimSpace(e.identity)) == 0 {
+		return fmt.Errorf("missing identity: failed to run leader election for '%s'", e.lockName)
+	}
+
+	c := &leaderCandidate{
+		lock:          e.lock(),
+		leaseDuration: e.leaseDuration,
+		renewDeadline: e.renewDeadline,
+		retryPeriod:   e.retryPeriod,
+	}
+	if !c.acquire(stopCh) {
+		return nil
+	}
+
+	leadCh, leadDoneCh := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(leadDoneCh)
+		lead(leadCh)
+	}()
+
+	err := c.renew(stopCh)
+	close(leadCh)
+	<-leadDoneCh
+	if err != nil {
+		return errors.Wrapf(err, "failed to renew leadership of '%s/%s': '%s' lost leadership", e.namespace, e.lockName, e.identity)
+	}
+
+	// a failure to release is not an error since the leadership is anyway
+	// taken over once the lease expires
+	err = c.release()
+	if err != nil {
+		utilruntime.HandleError(errors.Wrapf(err, "failed to release leadership of '%s/%s'", e.namespace, e.lockName))
+	}
+	return nil
+}
+
+// lock returns the config map lock of client-go used by this elector
+func (e *configMapLeaderElector) lock() resourcelock.Interface {
+	return &resourcelock.ConfigMapLock{
+		ConfigMapMeta: metav1.ObjectMeta{Namespace: e.namespace, Name: e.lockName},
+		Client:        e.client,
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity:      e.identity,
+			EventRecorder: e.recorder,
+		},
+	}
+}
+
+// leaderJitterFactor is the maximum factor by which the retry period is
+// extended while attempting to acquire the leadership
+const leaderJitterFactor = 1.2
+
+// leaderCandidate runs a single leader election against the given lock
+//
+// NOTE:
+//  The lock record was last observed to have changed at the observed time.
+// A lock held by another candidate is considered expired once its lease
+// duration elapses from the observed time. This does not depend on the
+// clocks of the candidates being in sync.
+type leaderCandidate struct {
+	lock           resourcelock.Interface
+	leaseDuration  time.Duration
+	renewDeadline  time.Duration
+	retryPeriod    time.Duration
+	observedRecord resourcelock.LeaderElectionRecord
+	observedTime   time.Time
+}
+
+// acquire attempts to acquire the leadership till it succeeds; it returns
+// false if the given stop channel is closed before the leadership is
+// acquired
+func (c *leaderCandidate) acquire(stopCh <-chan struct{}) bool {
+	for {
+		select {
+		case <-stopCh:
+			return false
+		default:
+		}
+
+		if c.tryAcquireOrRenew() {
+			c.lock.RecordEvent("became leader")
+			glog.Infof("successfully acquired leadership of '%s'", c.lock.Describe())
+			return true
+		}
+
+		select {
+		case <-stopCh:
+			return false
+		case <-time.After(wait.Jitter(c.retryPeriod, leaderJitterFactor)):
+		}
+	}
+}
+
+// renew keeps renewing the leadership till the given stop channel is closed;
+// it returns error if the leadership could not be renewed within the renew
+// deadline
+func (c *leaderCandidate) renew(stopCh <-chan struct{}) error {
+	renewed := time.Now()
+	ticker := time.NewTicker(c.retryPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		case <-ticker.C:
+		}
+
+		if c.tryAcquireOrRenew() {
+			renewed = time.Now()
+			continue
+		}
+
+		if time.Since(renewed) >= c.renewDeadline {
+			c.lock.RecordEvent("stopped leading")
+			return fmt.Errorf("failed to renew lease '%s' within '%s'", c.lock.Describe(), c.renewDeadline)
+		}
+	}
+}
+
+// release gives up the leadership by clearing the holder of the lock
+//
+// NOTE:
+//  A lock without a holder is acquired by the next candidate at once
+func (c *leaderCandidate) release() error {
+	if c.observedRecord.HolderIdentity != c.lock.Identity() {
+		return nil
+	}
+
+	now := metav1.Now()
+	record := resourcelock.LeaderElectionRecord{
+		LeaseDurationSeconds: 1,
+		AcquireTime:          now,
+		RenewTime:            now,
+		LeaderTransitions:    c.observedRecord.LeaderTransitions,
+	}
+	err := c.lock.Update(record)
+	if err != nil {
+		return err
+	}
+
+	c.observedRecord, c.observedTime = record, time.Now()
+	c.lock.RecordEvent("stopped leading")
+	return nil
+}
+
+// tryAcquireOrRenew acquires the leadership if it is not held or renews the
+// leadership if it is held by this candidate; it returns true on success
+func (c *leaderCandidate) tryAcquireOrRenew() bool {
+	now := metav1.Now()
+	record := resourcelock.LeaderElectionRecord{
+		HolderIdentity:       c.lock.Identity(),
+		LeaseDurationSeconds: int(c.leaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	old, err := c.lock.Get()
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			glog.Errorf("failed to get leader lock '%s': %v", c.lock.Describe(), err)
+			return false
+		}
+		err = c.lock.Create(record)
+		if err != nil {
+			glog.Errorf("failed to create leader lock '%s': %v", c.lock.Describe(), err)
+			return false
+		}
+		c.observedRecord, c.observedTime = record, time.Now()
+		return true
+	}
+
+	if !reflect.DeepEqual(c.observedRecord, *old) {
+		c.observedRecord, c.observedTime = *old, time.Now()
+	}
+
+	held := len(old.HolderIdentity) != 0 && old.HolderIdentity != c.lock.Identity()
+	if held && c.observedTime.Add(c.leaseDuration).After(now.Time) {
+		glog.V(4).Infof("leader lock '%s' is held by '%s'", c.lock.Describe(), old.HolderIdentity)
+		return false
+	}
+
+	if old.HolderIdentity == c.lock.Identity() {
+		record.AcquireTime = old.AcquireTime
+		record.LeaderTransitions = old.LeaderTransitions
+	} else {
+		record.LeaderTransitions = old.LeaderTransitions + 1
+	}
+
+	err = c.lock.Update(record)
+	if err != nil {
+		glog.Errorf("failed to update leader lock '%s': %v", c.lock.Describe(), err)
+		return false
+	}
+	c.observedRecord, c.observedTime = record, time.Now()
+	return true
+}
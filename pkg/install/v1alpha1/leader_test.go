@@ -0,0 +1,266 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+const testLeaderNamespace = "openebs"
+
+// newTestLeaderElector returns a leader elector with durations suitable for
+// tests
+func newTestLeaderElector(identity string, client corev1client.ConfigMapsGetter) *configMapLeaderElector {
+	e := NewConfigMapLeaderElector(testLeaderNamespace, "openebs-install", identity, client)
+	e.leaseDuration = time.Second
+	e.renewDeadline = 500 * time.Millisecond
+	e.retryPeriod = 50 * time.Millisecond
+	e.recorder = &record.FakeRecorder{}
+	return e
+}
+
+// leaderRecord returns the leader election record stored in the lock
+func leaderRecord(t *testing.T, client corev1client.ConfigMapsGetter) (record resourcelock.LeaderElectionRecord) {
+	cm, err := client.ConfigMaps(testLeaderNamespace).Get(leaderLockName("openebs-install"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	err = json.Unmarshal([]byte(cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey]), &record)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	return
+}
+
+// runLeaderElector runs the given elector in the background & returns the
+// channels to observe the leadership as well as the outcome of run
+func runLeaderElector(e LeaderElector, stopCh <-chan struct{}) (leading, stopped chan struct{}, result chan error) {
+	leading, stopped, result = make(chan struct{}), make(chan struct{}), make(chan error, 1)
+	go func() {
+		result <- e.Run(stopCh, func(leadCh <-chan struct{}) {
+			close(leading)
+			<-leadCh
+			close(stopped)
+		})
+	}()
+	return
+}
+
+// countingConfigMapsGetter counts the calls made to the config maps
+type countingConfigMapsGetter struct {
+	corev1client.ConfigMapsGetter
+	calls int32
+}
+
+// ConfigMaps is an implementation of corev1client.ConfigMapsGetter
+func (g *countingConfigMapsGetter) ConfigMaps(namespace string) corev1client.ConfigMapInterface {
+	atomic.AddInt32(&g.calls, 1)
+	return g.ConfigMapsGetter.ConfigMaps(namespace)
+}
+
+func TestLeaderElectorRunInvalid(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+	tests := map[string]struct {
+		elector *configMapLeaderElector
+	}{
+		"missing identity": {elector: newTestLeaderElector(" ", client)},
+		"nil client":       {elector: newTestLeaderElector("replica-a", nil)},
+	}
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := mock.elector.Run(make(chan struct{}), func(<-chan struct{}) {
+				t.Fatalf("expected no leadership")
+			})
+			if err == nil {
+				t.Fatalf("expected error: got nil")
+			}
+		})
+	}
+}
+
+func TestLeaderElectorAcquireAndStop(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+	stopCh := make(chan struct{})
+
+	leading, stopped, result := runLeaderElector(newTestLeaderElector("replica-a", client), stopCh)
+
+	select {
+	case <-leading:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected 'replica-a' to acquire leadership: timed out")
+	}
+
+	if record := leaderRecord(t, client); record.HolderIdentity != "replica-a" {
+		t.Fatalf("expected leader 'replica-a': got '%s'", record.HolderIdentity)
+	}
+
+	close(stopCh)
+	if err := <-result; err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatalf("expected leader to stop before run returns")
+	}
+}
+
+func TestLeaderElectorSingleLeader(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+	leaderStopCh, candidateStopCh := make(chan struct{}), make(chan struct{})
+	defer close(candidateStopCh)
+
+	leading, _, result := runLeaderElector(newTestLeaderElector("replica-a", client), leaderStopCh)
+	<-leading
+
+	candidateLeading, _, _ := runLeaderElector(newTestLeaderElector("replica-b", client), candidateStopCh)
+	select {
+	case <-candidateLeading:
+		t.Fatalf("expected 'replica-b' to not acquire leadership held by 'replica-a'")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	close(leaderStopCh)
+	if err := <-result; err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	// a stopped leader releases its leadership & the candidate takes over
+	// before the lease expires
+	select {
+	case <-candidateLeading:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatalf("expected 'replica-b' to acquire released leadership: timed out")
+	}
+
+	record := leaderRecord(t, client)
+	if record.HolderIdentity != "replica-b" {
+		t.Fatalf("expected leader 'replica-b': got '%s'", record.HolderIdentity)
+	}
+	if record.LeaderTransitions != 1 {
+		t.Fatalf("expected 1 leader transition: got '%d'", record.LeaderTransitions)
+	}
+}
+
+func TestLeaderElectorStopBeforeLeading(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+	candidateClient := &countingConfigMapsGetter{ConfigMapsGetter: client}
+	leaderStopCh, candidateStopCh := make(chan struct{}), make(chan struct{})
+	defer close(leaderStopCh)
+
+	leading, _, _ := runLeaderElector(newTestLeaderElector("replica-a", client), leaderStopCh)
+	<-leading
+
+	candidateLeading, _, result := runLeaderElector(newTestLeaderElector("replica-b", candidateClient), candidateStopCh)
+	// let the candidate attempt to acquire the leadership held by 'replica-a'
+	time.Sleep(200 * time.Millisecond)
+	close(candidateStopCh)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("expected no error: got '%v'", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected 'replica-b' to stop while acquiring leadership: timed out")
+	}
+
+	select {
+	case <-candidateLeading:
+		t.Fatalf("expected 'replica-b' to not acquire leadership")
+	default:
+	}
+
+	// a stopped candidate is expected to no longer call the lock
+	calls := atomic.LoadInt32(&candidateClient.calls)
+	time.Sleep(300 * time.Millisecond)
+	if got := atomic.LoadInt32(&candidateClient.calls); got != calls {
+		t.Fatalf("expected no call to the lock by stopped 'replica-b': got '%d' call(s)", got-calls)
+	}
+}
+
+func TestLeaderElectorReleaseOnStop(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+	stopCh := make(chan struct{})
+
+	leading, _, result := runLeaderElector(newTestLeaderElector("replica-a", client), stopCh)
+	<-leading
+
+	close(stopCh)
+	if err := <-result; err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	record := leaderRecord(t, client)
+	if record.HolderIdentity != "" {
+		t.Fatalf("expected released leadership: got leader '%s'", record.HolderIdentity)
+	}
+	if record.LeaderTransitions != 0 {
+		t.Fatalf("expected 0 leader transitions: got '%d'", record.LeaderTransitions)
+	}
+}
+
+func TestLeaderElectorLostLeadership(t *testing.T) {
+	client := fake.NewSimpleClientset().CoreV1()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	leading, stopped, result := runLeaderElector(newTestLeaderElector("replica-a", client), stopCh)
+	<-leading
+
+	// another replica takes over the lock
+	configMaps := client.ConfigMaps(testLeaderNamespace)
+	cm, err := configMaps.Get(leaderLockName("openebs-install"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	recordJSON, err := json.Marshal(resourcelock.LeaderElectionRecord{HolderIdentity: "replica-b", LeaseDurationSeconds: 15})
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	cm.Annotations[resourcelock.LeaderElectionRecordAnnotationKey] = string(recordJSON)
+	if _, err = configMaps.Update(cm); err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	select {
+	case err = <-result:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected 'replica-a' to lose leadership: timed out")
+	}
+
+	if err == nil {
+		t.Fatalf("expected error on losing leadership: got nil")
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatalf("expected leader to stop on losing leadership")
+	}
+}
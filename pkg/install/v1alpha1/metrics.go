@@ -0,0 +1,140 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsPath is the http path the metrics are served at
+const metricsPath = "/metrics"
+
+// metricsShutdownTimeout is the maximum time to wait for the metrics server
+// to shut down gracefully
+const metricsShutdownTimeout = 5 * time.Second
+
+var (
+	// InstallRunsTotal is the number of install runs by their result
+	InstallRunsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "openebs",
+			Subsystem: "install",
+			Name:      "runs_total",
+			Help:      "Number of install runs by result.",
+		},
+		[]string{"result"},
+	)
+
+	// InstallLastSuccessTimestamp is the unix time of the last successful
+	// install run
+	InstallLastSuccessTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "openebs",
+			Subsystem: "install",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "Unix time of the last successful install run.",
+		},
+	)
+
+	// InstallDriftedObjects is the number of resources that were found to be
+	// not in their desired state by the last drift detection run
+	InstallDriftedObjects = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "openebs",
+			Subsystem: "install",
+			Name:      "drifted_objects",
+			Help:      "Number of resources not in their desired state as per the last drift detection run.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(InstallRunsTotal, InstallLastSuccessTimestamp, InstallDriftedObjects)
+}
+
+// observeInstall records the metrics of the install run corresponding to the
+// given report
+func observeInstall(report InstallReport, finishedAt time.Time) {
+	if !report.IsSuccess() {
+		InstallRunsTotal.WithLabelValues("failure").Inc()
+		return
+	}
+
+	InstallRunsTotal.WithLabelValues("success").Inc()
+	InstallLastSuccessTimestamp.Set(float64(finishedAt.Unix()))
+}
+
+// observeDrift records the metrics of the drift detection run corresponding
+// to the given report
+//
+// NOTE:
+//  Resources that were healed are not considered as drifted
+func observeDrift(report DriftReport) {
+	drifted := 0
+	for _, item := range report.Items {
+		if item.Status != DriftStatusInSync && !item.Healed {
+			drifted++
+		}
+	}
+	InstallDriftedObjects.Set(float64(drifted))
+}
+
+// metricsAddress returns the address to serve the metrics at; empty if
+// metrics should not be served
+func metricsAddress() string {
+	return strings.TrimSpace(env.Get(string(EnvKeyForInstallMetricsAddress)))
+}
+
+// metricsHandler returns the http handler that serves the metrics
+func metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.Handler())
+	return mux
+}
+
+// ServeMetrics serves the metrics at the given address till the given stop
+// channel is closed
+func ServeMetrics(address string, stopCh <-chan struct{}) error {
+	if len(strings.TrimSpace(address)) == 0 {
+		return fmt.Errorf("missing address: failed to serve metrics")
+	}
+
+	server := &http.Server{Addr: address, Handler: metricsHandler()}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	glog.Infof("serving metrics at '%s%s'", address, metricsPath)
+	err := server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return errors.Wrapf(err, "failed to serve metrics at '%s'", address)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveInstall(t *testing.T) {
+	tests := map[string]struct {
+		report          InstallReport
+		expectResult    string
+		expectTimestamp bool
+	}{
+		"successful install": {
+			report:          InstallReport{Items: []InstallReportItem{{Action: InstallActionCreated}}},
+			expectResult:    "success",
+			expectTimestamp: true,
+		},
+		"failed install": {
+			report:       InstallReport{Errors: []string{"boom"}},
+			expectResult: "failure",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			InstallLastSuccessTimestamp.Set(0)
+			counter := InstallRunsTotal.WithLabelValues(mock.expectResult)
+			before := testutil.ToFloat64(counter)
+			finishedAt := time.Unix(1540000000, 0)
+
+			observeInstall(mock.report, finishedAt)
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Fatalf("expected '%s' install runs to increase by 1: got '%v'", mock.expectResult, got)
+			}
+
+			timestamp := testutil.ToFloat64(InstallLastSuccessTimestamp)
+			if mock.expectTimestamp && timestamp != float64(finishedAt.Unix()) {
+				t.Fatalf("expected last success timestamp '%d': got '%v'", finishedAt.Unix(), timestamp)
+			}
+			if !mock.expectTimestamp && timestamp != 0 {
+				t.Fatalf("expected last success timestamp to be unchanged: got '%v'", timestamp)
+			}
+		})
+	}
+}
+
+func TestObserveDrift(t *testing.T) {
+	report := DriftReport{
+		Items: []DriftItem{
+			{Name: "in-sync", Status: DriftStatusInSync},
+			{Name: "drifted", Status: DriftStatusDrifted},
+			{Name: "missing", Status: DriftStatusMissing},
+			{Name: "unknown", Status: DriftStatusUnknown},
+			{Name: "healed", Status: DriftStatusDrifted, Healed: true},
+		},
+	}
+
+	observeDrift(report)
+
+	if got := testutil.ToFloat64(InstallDriftedObjects); got != 3 {
+		t.Fatalf("expected 3 drifted objects: got '%v'", got)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	InstallDriftedObjects.Set(2)
+
+	recorder := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", metricsPath, nil))
+
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		"openebs_install_drifted_objects 2",
+		"openebs_install_last_success_timestamp_seconds",
+		"# TYPE openebs_install_runs_total counter",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatalf("expected metrics to contain '%s': got '%s'", expected, body)
+		}
+	}
+}
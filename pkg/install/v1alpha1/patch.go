@@ -0,0 +1,236 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// PatchType is a typed string to represent the kind of a patch
+type PatchType string
+
+const (
+	// PatchTypeJSON is a RFC 6902 JSON patch i.e. a list of operations
+	PatchTypeJSON PatchType = "json"
+	// PatchTypeMerge is a RFC 7386 JSON merge patch
+	PatchTypeMerge PatchType = "merge"
+)
+
+// PatchTarget selects the artifacts a patch is applied to; an artifact is
+// selected if it matches all the specified fields
+type PatchTarget struct {
+	// Group of the artifact e.g. openebs.io
+	Group string `json:"group,omitempty"`
+	// Version of the artifact e.g. v1alpha1
+	Version string `json:"version,omitempty"`
+	// Kind of the artifact e.g. CASTemplate
+	Kind string `json:"kind,omitempty"`
+	// Name is a glob pattern on the name of the artifact
+	Name string `json:"name,omitempty"`
+	// LabelSelector on the labels of the artifact
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// String returns the target as comma separated key value pairs
+func (t PatchTarget) String() string {
+	var pairs []string
+	for _, pair := range [][2]string{
+		{"group", t.Group}, {"version", t.Version}, {"kind", t.Kind}, {"name", t.Name}, {"labelSelector", t.LabelSelector},
+	} {
+		if len(pair[1]) != 0 {
+			pairs = append(pairs, pair[0]+"="+pair[1])
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// matches returns true if the given unstructured instance matches all the
+// specified fields of this target
+func (t PatchTarget) matches(unstruct *unstructured.Unstructured) (bool, error) {
+	gvk := unstruct.GroupVersionKind()
+	if (len(t.Group) != 0 && t.Group != gvk.Group) ||
+		(len(t.Version) != 0 && t.Version != gvk.Version) ||
+		(len(t.Kind) != 0 && t.Kind != gvk.Kind) {
+		return false, nil
+	}
+
+	if len(t.Name) != 0 {
+		matched, err := path.Match(t.Name, unstruct.GetName())
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid patch target '%s'", t)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if len(t.LabelSelector) != 0 {
+		selector, err := labels.Parse(t.LabelSelector)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid patch target '%s'", t)
+		}
+		return selector.Matches(labels.Set(unstruct.GetLabels())), nil
+	}
+
+	return true, nil
+}
+
+// Patch is applied to the artifacts selected by its target before they are
+// installed
+type Patch struct {
+	// Target selects the artifacts to be patched
+	Target PatchTarget `json:"target"`
+	// Type of this patch; json or merge
+	Type PatchType `json:"type"`
+	// Patch is the patch document in JSON or YAML
+	Patch string `json:"patch"`
+}
+
+// patchOperation has the fields of a JSON patch operation that identify it
+type patchOperation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+}
+
+// String returns the operation as op & path e.g. replace /spec/replicas
+func (o patchOperation) String() string {
+	return o.Op + " " + o.Path
+}
+
+// decode returns the JSON document of this patch along with its operations
+// if this is a JSON patch
+func (p Patch) decode() (doc []byte, ops []patchOperation, err error) {
+	doc, err = yaml.YAMLToJSON([]byte(p.Patch))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid %s patch for target '%s'", p.Type, p.Target)
+	}
+
+	switch p.Type {
+	case PatchTypeJSON:
+		_, err = jsonpatch.DecodePatch(doc)
+		if err == nil {
+			err = json.Unmarshal(doc, &ops)
+		}
+	case PatchTypeMerge:
+		err = json.Unmarshal(doc, &map[string]interface{}{})
+	default:
+		err = fmt.Errorf("unsupported patch type '%s': supported types are '%s' and '%s'", p.Type, PatchTypeJSON, PatchTypeMerge)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid %s patch for target '%s'", p.Type, p.Target)
+	}
+
+	return doc, ops, nil
+}
+
+// validatePatches returns the errors of the given patches that can not be
+// applied irrespective of the artifacts they target
+func validatePatches(patches []Patch) (errs []error) {
+	for _, p := range patches {
+		if _, _, err := p.decode(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if _, err := path.Match(p.Target.Name, ""); err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid patch target '%s'", p.Target))
+		}
+
+		if _, err := labels.Parse(p.Target.LabelSelector); err != nil {
+			errs = append(errs, errors.Wrapf(err, "invalid patch target '%s'", p.Target))
+		}
+	}
+	return
+}
+
+// applyPatch applies the given patch to the given unstructured instance
+//
+// NOTE:
+//  The operations of a JSON patch are applied one after the other so that
+// the error names the operation that failed
+func applyPatch(unstruct *unstructured.Unstructured, p Patch) (*unstructured.Unstructured, error) {
+	patchDoc, ops, err := p.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := json.Marshal(unstruct.Object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to apply %s patch to '%s'", p.Type, unstruct.GetName())
+	}
+
+	if p.Type == PatchTypeMerge {
+		doc, err = jsonpatch.MergePatch(doc, patchDoc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply %s patch to '%s'", p.Type, unstruct.GetName())
+		}
+	} else {
+		// the patch is valid since it got decoded
+		operations, _ := jsonpatch.DecodePatch(patchDoc)
+		for idx := range operations {
+			doc, err = operations[idx : idx+1].Apply(doc)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to apply %s patch op '%s' to '%s'", p.Type, ops[idx], unstruct.GetName())
+			}
+		}
+	}
+
+	patched, err := k8s.BuildUnstructured(doc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to apply %s patch to '%s'", p.Type, unstruct.GetName())
+	}
+	return patched, nil
+}
+
+// updateUnstructuredPatches applies the install patches that target the
+// unstructured instance in the order they are specified
+//
+// NOTE:
+//  An instance that fails to be patched is dropped & the failure is
+// accumulated in the installer's error list
+//
+// NOTE:
+//  This is an implementation of WithInstallUnstructuredUpdater
+func (i *simpleInstaller) updateUnstructuredPatches(install Install) k8s.UnstructuredMiddleware {
+	return func(unstructured *unstructured.Unstructured) *unstructured.Unstructured {
+		if unstructured == nil {
+			return unstructured
+		}
+
+		for _, p := range install.SetOptions.Patches {
+			matched, err := p.Target.matches(unstructured)
+			if err == nil && matched {
+				unstructured, err = applyPatch(unstructured, p)
+			}
+			if err != nil {
+				i.addError(errors.Wrapf(err, "simple installer failed to patch '%s' for version '%s'", p.Target, install.Version))
+				return nil
+			}
+		}
+		return unstructured
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// jivaVolumeCreateUnstructured returns the unstructured instance of jiva
+// volume create cas template
+func jivaVolumeCreateUnstructured(t *testing.T) *unstructured.Unstructured {
+	unstructs, errs := TransformArtifactToUnstructuredList(ArtifactList{Items: []*Artifact{jivaVolumeCreateDefault070()}})
+	if len(errs) != 0 {
+		t.Fatalf("expected no error: got '%v'", errs)
+	}
+	return unstructs[0]
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := map[string]struct {
+		patch       Patch
+		expectPath  []string
+		expectValue string
+		expectErr   string
+	}{
+		"json patch replaces default config": {
+			patch: Patch{
+				Type:  PatchTypeJSON,
+				Patch: "- op: test\n  path: /spec/defaultConfig/3/name\n  value: ReplicaCount\n- op: replace\n  path: /spec/defaultConfig/3/value\n  value: \"1\"\n",
+			},
+			expectPath:  []string{"spec", "defaultConfig", "3", "value"},
+			expectValue: "1",
+		},
+		"merge patch sets task namespace": {
+			patch:       Patch{Type: PatchTypeMerge, Patch: `{"spec": {"taskNamespace": "storage"}}`},
+			expectPath:  []string{"spec", "taskNamespace"},
+			expectValue: "storage",
+		},
+		"failed op is named": {
+			patch: Patch{
+				Type:  PatchTypeJSON,
+				Patch: "- op: test\n  path: /metadata/name\n  value: jiva-volume-create-default-0.7.0\n- op: remove\n  path: /spec/missing\n",
+			},
+			expectErr: "op 'remove /spec/missing'",
+		},
+		"unsupported patch type": {
+			patch:     Patch{Type: "strategic", Patch: "{}"},
+			expectErr: "unsupported patch type 'strategic'",
+		},
+		"json patch that is not a list": {
+			patch:     Patch{Type: PatchTypeJSON, Patch: "op: add"},
+			expectErr: "invalid json patch",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			patched, err := applyPatch(jivaVolumeCreateUnstructured(t), mock.patch)
+			if len(mock.expectErr) != 0 {
+				if err == nil || !strings.Contains(err.Error(), mock.expectErr) {
+					t.Fatalf("expected error having '%s': got '%v'", mock.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+
+			if got := nestedValue(patched.Object, mock.expectPath...); got != mock.expectValue {
+				t.Fatalf("expected '%s' at '%s': got '%v'", mock.expectValue, strings.Join(mock.expectPath, "."), got)
+			}
+		})
+	}
+}
+
+// nestedValue returns the value found at the given path of fields where a
+// numeric field is an index of a list
+func nestedValue(obj interface{}, fields ...string) interface{} {
+	for _, field := range fields {
+		switch typed := obj.(type) {
+		case map[string]interface{}:
+			obj = typed[field]
+		case []interface{}:
+			var idx int
+			for _, c := range field {
+				idx = idx*10 + int(c-'0')
+			}
+			if idx >= len(typed) {
+				return nil
+			}
+			obj = typed[idx]
+		default:
+			return nil
+		}
+	}
+	return obj
+}
+
+func TestPatchTargetMatches(t *testing.T) {
+	unstruct := jivaVolumeCreateUnstructured(t)
+	unstruct.SetLabels(map[string]string{"openebs.io/cas-type": "jiva"})
+
+	tests := map[string]struct {
+		target   PatchTarget
+		expected bool
+	}{
+		"everything":          {expected: true},
+		"group version kind":  {target: PatchTarget{Group: "openebs.io", Version: "v1alpha1", Kind: "CASTemplate"}, expected: true},
+		"other kind":          {target: PatchTarget{Kind: "RunTask"}},
+		"name pattern":        {target: PatchTarget{Name: "jiva-volume-*"}, expected: true},
+		"other name pattern":  {target: PatchTarget{Name: "cstor-volume-*"}},
+		"label selector":      {target: PatchTarget{LabelSelector: "openebs.io/cas-type=jiva"}, expected: true},
+		"other label":         {target: PatchTarget{LabelSelector: "openebs.io/cas-type in (cstor)"}},
+		"kind and other name": {target: PatchTarget{Kind: "CASTemplate", Name: "jiva-volume-delete-*"}},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.target.matches(unstruct)
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if got != mock.expected {
+				t.Fatalf("expected match '%t': got '%t'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateUnstructuredPatches(t *testing.T) {
+	install := Install{
+		Version: "0.7.0",
+		SetOptions: SetOptions{
+			Patches: []Patch{
+				{
+					Target: PatchTarget{Kind: "CASTemplate", Name: "jiva-volume-create-default-0.7.0"},
+					Type:   PatchTypeJSON,
+					Patch:  `[{"op": "replace", "path": "/spec/defaultConfig/3/value", "value": "1"}]`,
+				},
+				{
+					Target: PatchTarget{Kind: "CASTemplate", Name: "jiva-volume-delete-*"},
+					Type:   PatchTypeJSON,
+					Patch:  `[{"op": "remove", "path": "/spec/missing"}]`,
+				},
+			},
+		},
+	}
+
+	i := NewSimpleInstaller(nil)
+	unstructs := i.installUnstructuredList(install)
+
+	var found bool
+	for _, unstruct := range unstructs {
+		switch unstruct.GetName() {
+		case "jiva-volume-create-default-0.7.0":
+			found = true
+			if got := nestedValue(unstruct.Object, "spec", "defaultConfig", "3", "value"); got != "1" {
+				t.Fatalf("expected patched replica count '1': got '%v'", got)
+			}
+		case "jiva-volume-delete-default-0.7.0":
+			t.Fatalf("expected instance that failed to be patched to be dropped: got '%s'", unstruct.GetName())
+		}
+	}
+	if !found {
+		t.Fatalf("expected patched instance 'jiva-volume-create-default-0.7.0': got none")
+	}
+
+	if len(i.errors) != 1 || !strings.Contains(i.errors[0].Error(), "name=jiva-volume-delete-*") || !strings.Contains(i.errors[0].Error(), "remove /spec/missing") {
+		t.Fatalf("expected error naming the target & the failed op: got '%v'", i.errors)
+	}
+}
+
+func TestValidatePatches(t *testing.T) {
+	tests := map[string]struct {
+		patches   []Patch
+		expectErr bool
+	}{
+		"valid":                  {patches: []Patch{{Type: PatchTypeMerge, Patch: "spec: {}"}}},
+		"invalid yaml":           {patches: []Patch{{Type: PatchTypeMerge, Patch: "spec: ["}}, expectErr: true},
+		"invalid name pattern":   {patches: []Patch{{Type: PatchTypeMerge, Patch: "{}", Target: PatchTarget{Name: "jiva-["}}}, expectErr: true},
+		"invalid label selector": {patches: []Patch{{Type: PatchTypeMerge, Patch: "{}", Target: PatchTarget{LabelSelector: "a in"}}}, expectErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if errs := validatePatches(mock.patches); mock.expectErr != (len(errs) != 0) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, errs)
+			}
+		})
+	}
+}
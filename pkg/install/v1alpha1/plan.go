@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PlanAction is a typed string to represent the action that will be taken
+// against a resource during install
+type PlanAction string
+
+const (
+	// PlanActionCreate indicates the resource will be created
+	PlanActionCreate PlanAction = "create"
+	// PlanActionUpdate indicates the resource will be updated
+	PlanActionUpdate PlanAction = "update"
+	// PlanActionNoop indicates the resource is already in its desired state
+	PlanActionNoop PlanAction = "no-op"
+	// PlanActionUnknown indicates the action could not be determined
+	PlanActionUnknown PlanAction = "unknown"
+)
+
+// PlanItem represents the planned action against a single resource
+type PlanItem struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Namespace  string          `json:"namespace,omitempty"`
+	Name       string          `json:"name"`
+	Action     PlanAction      `json:"action"`
+	Diffs      []k8s.FieldDiff `json:"diffs,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// InstallPlan is the list of planned actions against the resources
+// specified in the install config
+type InstallPlan struct {
+	Items []PlanItem `json:"items"`
+}
+
+// Count returns the number of plan items with the given action
+func (p InstallPlan) Count(action PlanAction) (count int) {
+	for _, item := range p.Items {
+		if item.Action == action {
+			count++
+		}
+	}
+	return
+}
+
+// JSON returns the JSON representation of this plan
+func (p InstallPlan) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// String returns the human readable representation of this plan
+func (p InstallPlan) String() string {
+	var buf bytes.Buffer
+
+	for _, item := range p.Items {
+		name := item.Name
+		if len(item.Namespace) != 0 {
+			name = item.Namespace + "/" + item.Name
+		}
+
+		fmt.Fprintf(&buf, "%-7s %s/%s %s\n", item.Action, item.APIVersion, item.Kind, name)
+		for _, diff := range item.Diffs {
+			fmt.Fprintf(&buf, "    %s\n", diff)
+		}
+
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, "    error: %s\n", item.Error)
+		}
+	}
+
+	fmt.Fprintf(&buf, "\nPlan: %d to create, %d to update, %d unchanged, %d unknown\n",
+		p.Count(PlanActionCreate), p.Count(PlanActionUpdate), p.Count(PlanActionNoop), p.Count(PlanActionUnknown))
+
+	return buf.String()
+}
+
+// Planner abstracts planning of the resources that would be installed
+type Planner interface {
+	Plan(ctx context.Context) (plan InstallPlan, errors []error)
+}
+
+// planUnstructured returns the planned action against the given
+// unstructured instance by comparing it with its live counterpart that is
+// fetched within the given context
+func planUnstructured(ctx context.Context, desired *unstructured.Unstructured) (item PlanItem, err error) {
+	item = PlanItem{
+		APIVersion: desired.GetAPIVersion(),
+		Kind:       desired.GetKind(),
+		Namespace:  desired.GetNamespace(),
+		Name:       desired.GetName(),
+		Action:     PlanActionUnknown,
+	}
+
+	get := k8s.NewResourceGetterWithContext(ctx, GroupVersionResourceFromGVK(desired), desired.GetNamespace())
+	live, err := get(desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		item.Action = PlanActionCreate
+		return item, nil
+	}
+
+	if err != nil {
+		err = errors.Wrapf(err, "failed to plan resource '%s'", item.Name)
+		item.Error = err.Error()
+		return
+	}
+
+	item.Diffs = k8s.DiffUnstructured(live, desired)
+	if len(item.Diffs) == 0 {
+		item.Action = PlanActionNoop
+	} else {
+		item.Action = PlanActionUpdate
+	}
+
+	return
+}
+
+// Plan compares the resources specified in the install config against the
+// live resources found in kubernetes cluster
+//
+// NOTE:
+//  Hooks are not planned since they are re-created on every install. The
+// resources are planned till the given context is done.
+//
+// NOTE:
+//  This is an implementation of Planner interface
+func (i *simpleInstaller) Plan(ctx context.Context) (plan InstallPlan, errs []error) {
+	for _, unstruct := range i.orderedMainUnstructuredList(ctx) {
+		item, err := planUnstructured(ctx, unstruct)
+		if err != nil {
+			i.addError(err)
+		}
+		plan.Items = append(plan.Items, item)
+	}
+
+	return plan, i.errors
+}
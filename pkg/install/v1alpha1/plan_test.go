@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+)
+
+func TestInstallPlan(t *testing.T) {
+	tests := map[string]struct {
+		plan          InstallPlan
+		expectCounts  map[PlanAction]int
+		expectStrings []string
+	}{
+		"empty plan": {
+			expectStrings: []string{"Plan: 0 to create, 0 to update, 0 unchanged, 0 unknown"},
+		},
+		"plan with every action": {
+			plan: InstallPlan{Items: []PlanItem{
+				{APIVersion: "v1", Kind: "Namespace", Name: "openebs", Action: PlanActionNoop},
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: "openebs", Name: "one", Action: PlanActionCreate},
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: "openebs", Name: "two", Action: PlanActionCreate},
+				{
+					APIVersion: "openebs.io/v1alpha1", Kind: "CASTemplate", Name: "cast", Action: PlanActionUpdate,
+					Diffs: []k8s.FieldDiff{{Path: "spec.output", Live: "old", Desired: "new"}},
+				},
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: "openebs", Name: "three", Action: PlanActionUnknown, Error: "boom"},
+			}},
+			expectCounts: map[PlanAction]int{PlanActionCreate: 2, PlanActionUpdate: 1, PlanActionNoop: 1, PlanActionUnknown: 1},
+			expectStrings: []string{
+				"no-op   v1/Namespace openebs\n",
+				"create  v1/ConfigMap openebs/one\n",
+				"update  openebs.io/v1alpha1/CASTemplate cast\n    ~ spec.output: old => new\n",
+				"unknown v1/ConfigMap openebs/three\n    error: boom\n",
+				"Plan: 2 to create, 1 to update, 1 unchanged, 1 unknown",
+			},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, action := range []PlanAction{PlanActionCreate, PlanActionUpdate, PlanActionNoop, PlanActionUnknown} {
+				if got := mock.plan.Count(action); got != mock.expectCounts[action] {
+					t.Fatalf("expected '%d' items to '%s': got '%d'", mock.expectCounts[action], action, got)
+				}
+			}
+
+			plan := mock.plan.String()
+			for _, expected := range mock.expectStrings {
+				if !strings.Contains(plan, expected) {
+					t.Fatalf("expected plan to contain '%s': got '%s'", expected, plan)
+				}
+			}
+
+			raw, err := mock.plan.JSON()
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			var decoded InstallPlan
+			if err = json.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if len(decoded.Items) != len(mock.plan.Items) {
+				t.Fatalf("expected '%d' plan items in json: got '%d'", len(mock.plan.Items), len(decoded.Items))
+			}
+		})
+	}
+}
+
+func TestPlanEveryResource(t *testing.T) {
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+		return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+	})
+
+	expected := len(i.orderedMainUnstructuredList(context.Background()))
+	if expected == 0 {
+		t.Fatalf("expected resources to plan: got none")
+	}
+
+	// resources that could not be compared with their live counterparts are
+	// planned as unknown
+	plan, _ := i.Plan(context.Background())
+	if len(plan.Items) != expected {
+		t.Fatalf("expected '%d' plan items: got '%d'", expected, len(plan.Items))
+	}
+	for _, item := range plan.Items {
+		if item.Action == PlanActionUnknown && len(item.Error) == 0 {
+			t.Fatalf("expected error for unknown action: got '%+v'", item)
+		}
+	}
+}
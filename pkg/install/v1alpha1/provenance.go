@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ProvenanceAnnotationKey is a typed string to represent the annotations
+// that record the origin of an installed resource
+type ProvenanceAnnotationKey string
+
+const (
+	// ProvenanceAnnotationKeySourceArtifact is the annotation that has the
+	// name of the artifact the resource is rendered from
+	ProvenanceAnnotationKeySourceArtifact ProvenanceAnnotationKey = "install.openebs.io/source-artifact"
+	// ProvenanceAnnotationKeyDigest is the annotation that has the content
+	// digest of the rendered resource
+	ProvenanceAnnotationKeyDigest ProvenanceAnnotationKey = "install.openebs.io/digest"
+)
+
+// updateUnstructuredSourceArtifact sets the annotation that has the name of
+// the artifact the unstructured instance is rendered from
+//
+// NOTE:
+//  An existing source artifact annotation is retained since the resource
+// may have been renamed by a previous updater
+//
+// NOTE:
+//  This is an implementation of WithInstallUnstructuredUpdater
+func updateUnstructuredSourceArtifact(install Install) k8s.UnstructuredMiddleware {
+	return func(unstructured *unstructured.Unstructured) *unstructured.Unstructured {
+		if unstructured == nil {
+			return unstructured
+		}
+
+		annotations := unstructured.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if len(annotations[string(ProvenanceAnnotationKeySourceArtifact)]) != 0 {
+			return unstructured
+		}
+
+		annotations[string(ProvenanceAnnotationKeySourceArtifact)] = unstructured.GetName()
+		unstructured.SetAnnotations(annotations)
+		return unstructured
+	}
+}
+
+// updateUnstructuredDigest sets the annotation that has the content digest
+// of the unstructured instance
+//
+// NOTE:
+//  This needs to be the last updater since the digest covers the changes
+// made by all the other updaters
+//
+// NOTE:
+//  This is an implementation of WithInstallUnstructuredUpdater
+func updateUnstructuredDigest(install Install) k8s.UnstructuredMiddleware {
+	return func(unstructured *unstructured.Unstructured) *unstructured.Unstructured {
+		if unstructured == nil {
+			return unstructured
+		}
+
+		digest, err := DigestUnstructured(unstructured)
+		if err != nil {
+			return unstructured
+		}
+
+		annotations := unstructured.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[string(ProvenanceAnnotationKeyDigest)] = digest
+		unstructured.SetAnnotations(annotations)
+		return unstructured
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"testing"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+)
+
+func TestProvenanceUpdaters(t *testing.T) {
+	os.Setenv(string(EnvKeyForInstallConfigName), "openebs-install")
+	defer os.Unsetenv(string(EnvKeyForInstallConfigName))
+
+	install := Install{
+		Version: "0.7.0",
+		SetOptions: SetOptions{
+			Namespace:   "openebs",
+			Labels:      map[string]string{"team": "storage", string(InstallManagedByLabelKey): "someone-else"},
+			Annotations: map[string]string{"owner": "storage"},
+		},
+	}
+
+	unstruct := fakeUnstructured("ConfigMap", "cstor-volume-create-output-default-0.7.0")
+	unstruct.SetLabels(map[string]string{"existing": "true"})
+
+	update := k8s.UnstructuredUpdater(WithInstallUnstructuredUpdaterList(install, NewSimpleInstaller(nil).unstructuredUpdaters))
+	updated := update(unstruct)
+
+	expectedLabels := map[string]string{
+		"existing":                        "true",
+		"team":                            "storage",
+		string(InstallManagedByLabelKey):  InstallManagedByLabelValue,
+		string(InstallVersionLabelKey):    "0.7.0",
+		string(InstallConfigNameLabelKey): "openebs-install",
+	}
+	for key, value := range expectedLabels {
+		if got := updated.GetLabels()[key]; got != value {
+			t.Fatalf("expected label '%s' to be '%s': got '%s'", key, value, got)
+		}
+	}
+
+	annotations := updated.GetAnnotations()
+	if annotations["owner"] != "storage" {
+		t.Fatalf("expected install annotation to be merged: got '%v'", annotations)
+	}
+	if annotations[string(ProvenanceAnnotationKeySourceArtifact)] != "cstor-volume-create-output-default-0.7.0" {
+		t.Fatalf("expected source artifact annotation: got '%v'", annotations)
+	}
+
+	digest, err := DigestUnstructured(updated)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	if annotations[string(ProvenanceAnnotationKeyDigest)] != digest {
+		t.Fatalf("expected digest annotation '%s': got '%s'", digest, annotations[string(ProvenanceAnnotationKeyDigest)])
+	}
+}
+
+func TestDigestUnstructuredIgnoresDigestAnnotation(t *testing.T) {
+	unstruct := fakeUnstructured("ConfigMap", "one")
+	before, err := DigestUnstructured(unstruct)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	annotated := updateUnstructuredDigest(Install{})(unstruct.DeepCopy())
+	after, err := DigestUnstructured(annotated)
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	if before != after || annotated.GetAnnotations()[string(ProvenanceAnnotationKeyDigest)] != before {
+		t.Fatalf("expected digest '%s' to be retained after annotating: got '%s'", before, after)
+	}
+}
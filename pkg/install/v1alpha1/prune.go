@@ -0,0 +1,237 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// InstallLabelKey is a typed string to represent the labels that are set
+// against the installed resources
+type InstallLabelKey string
+
+const (
+	// InstallConfigNameLabelKey is the label that has the name of the install
+	// config that owns the resource
+	InstallConfigNameLabelKey InstallLabelKey = "install.openebs.io/config-name"
+	// InstallVersionLabelKey is the label that has the install version the
+	// resource belongs to
+	InstallVersionLabelKey InstallLabelKey = "install.openebs.io/version"
+	// InstallManagedByLabelKey is the label that has the name of the tool
+	// managing the resource
+	InstallManagedByLabelKey InstallLabelKey = "app.kubernetes.io/managed-by"
+)
+
+// InstallManagedByLabelValue is the value of managed by label set against
+// the installed resources
+const InstallManagedByLabelValue = "decide"
+
+// isPruneEnabled returns true if install should delete the resources that
+// are no longer specified in the install config
+func isPruneEnabled() bool {
+	prune, _ := strconv.ParseBool(env.Get(string(EnvKeyForInstallPrune)))
+	return prune
+}
+
+// updateUnstructuredOwnership sets the labels that identify the tool, the
+// install config & the install version owning the unstructured instance
+//
+// NOTE:
+//  Other existing labels are retained. The ownership labels take precedence
+// over the install labels. The install config label is not set if the name
+// of install config is not known.
+//
+// NOTE:
+//  This is an implementation of WithInstallUnstructuredUpdater
+func updateUnstructuredOwnership(install Install) k8s.UnstructuredMiddleware {
+	return func(unstructured *unstructured.Unstructured) *unstructured.Unstructured {
+		if unstructured == nil {
+			return unstructured
+		}
+
+		lbls := unstructured.GetLabels()
+		if lbls == nil {
+			lbls = map[string]string{}
+		}
+		lbls[string(InstallManagedByLabelKey)] = InstallManagedByLabelValue
+		lbls[string(InstallVersionLabelKey)] = install.Version
+
+		configName := strings.TrimSpace(env.Get(string(EnvKeyForInstallConfigName)))
+		if len(configName) != 0 {
+			lbls[string(InstallConfigNameLabelKey)] = configName
+		}
+
+		unstructured.SetLabels(lbls)
+		return unstructured
+	}
+}
+
+// PruneAllowlist is the list of kinds whose resources may be pruned
+//
+// NOTE:
+//  Resources of other kinds are never pruned even if they carry the
+// ownership labels
+type PruneAllowlist []schema.GroupVersionKind
+
+// DefaultPruneAllowlist returns the kinds of the artifacts that are
+// understood by installer
+func DefaultPruneAllowlist() PruneAllowlist {
+	return PruneAllowlist{
+		{Group: "openebs.io", Version: "v1alpha1", Kind: "CASTemplate"},
+		{Group: "openebs.io", Version: "v1alpha1", Kind: "RunTask"},
+		{Group: "", Version: "v1", Kind: "ConfigMap"},
+	}
+}
+
+// PruneAction is a typed string to represent the action taken against a
+// resource that is no longer specified in the install config
+type PruneAction string
+
+const (
+	// PruneActionDeleted indicates the resource was deleted
+	PruneActionDeleted PruneAction = "deleted"
+	// PruneActionWouldDelete indicates the resource would be deleted; used in
+	// dry run
+	PruneActionWouldDelete PruneAction = "would-delete"
+	// PruneActionFailed indicates the resource could not be deleted
+	PruneActionFailed PruneAction = "failed"
+)
+
+// PruneReportItem is the outcome of pruning a single resource
+type PruneReportItem struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Version    string      `json:"version,omitempty"`
+	Action     PruneAction `json:"action"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Pruner abstracts deleting the resources that are owned by the install
+// config but are no longer specified in it
+type Pruner interface {
+	Prune(ctx context.Context, dryRun bool) (items []PruneReportItem, errors []error)
+}
+
+// Prune deletes the resources that carry the ownership labels of the install
+// config but are no longer specified in the install config
+//
+// NOTE:
+//  Nothing is pruned if the install config could not be resolved completely.
+// Nothing is deleted in dry run. Pruning stops once the given context is done.
+//
+// NOTE:
+//  This is an implementation of Pruner interface
+func (i *simpleInstaller) Prune(ctx context.Context, dryRun bool) ([]PruneReportItem, []error) {
+	desired := i.orderedUnstructuredList(ctx)
+	if len(i.errors) != 0 {
+		return nil, i.addError(fmt.Errorf("incomplete install config: simple installer failed to prune"))
+	}
+
+	items, err := i.pruneUnlisted(ctx, desired, dryRun)
+	if err != nil {
+		i.addError(err)
+	}
+
+	return items, i.errors
+}
+
+// pruneConfigName returns the name of the install config that owns the
+// resources to be pruned
+//
+// NOTE:
+//  The installed resources are labelled with this name
+func pruneConfigName() string {
+	return strings.TrimSpace(env.Get(string(EnvKeyForInstallConfigName)))
+}
+
+// pruneUnlisted deletes the resources of the allowed kinds that are owned by
+// the install config but are not amongst the given desired resources within
+// the given context
+func (i *simpleInstaller) pruneUnlisted(ctx context.Context, desired []*unstructured.Unstructured, dryRun bool) (items []PruneReportItem, err error) {
+	configName := pruneConfigName()
+	if len(configName) == 0 {
+		return nil, fmt.Errorf("missing install config name: failed to prune")
+	}
+
+	isDesired := map[string]bool{}
+	for _, unstruct := range desired {
+		isDesired[unstructuredKey(unstruct)] = true
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{string(InstallConfigNameLabelKey): configName}).String()
+	for _, gvk := range i.pruneAllowlist {
+		kind := &unstructured.Unstructured{}
+		kind.SetGroupVersionKind(gvk)
+		gvr := GroupVersionResourceFromGVK(kind)
+
+		owned, err := k8s.NewResourceListerWithContext(ctx, gvr, "")(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return items, errors.Wrapf(err, "failed to prune '%s'", gvr.Resource)
+		}
+
+		for idx := range owned.Items {
+			live := &owned.Items[idx]
+			live.SetGroupVersionKind(gvk)
+			if isDesired[unstructuredKey(live)] {
+				continue
+			}
+			items = append(items, pruneUnstructured(ctx, live, gvr, dryRun))
+		}
+	}
+
+	return
+}
+
+// pruneUnstructured deletes the given live resource
+func pruneUnstructured(ctx context.Context, live *unstructured.Unstructured, gvr schema.GroupVersionResource, dryRun bool) PruneReportItem {
+	item := PruneReportItem{
+		APIVersion: live.GetAPIVersion(),
+		Kind:       live.GetKind(),
+		Namespace:  live.GetNamespace(),
+		Name:       live.GetName(),
+		Version:    live.GetLabels()[string(InstallVersionLabelKey)],
+		Action:     PruneActionWouldDelete,
+	}
+
+	if dryRun {
+		return item
+	}
+
+	err := k8s.NewResourceDeleterWithContext(ctx, gvr, live.GetNamespace())(live.GetName(), &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		item.Action = PruneActionFailed
+		item.Error = errors.Wrapf(err, "failed to prune resource '%s'", live.GetName()).Error()
+		return item
+	}
+
+	item.Action = PruneActionDeleted
+	return item
+}
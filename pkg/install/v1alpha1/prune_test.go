@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUpdateUnstructuredOwnership(t *testing.T) {
+	tests := map[string]struct {
+		configName   string
+		labels       map[string]string
+		expectLabels map[string]string
+	}{
+		"without install config name": {
+			expectLabels: map[string]string{
+				string(InstallManagedByLabelKey): InstallManagedByLabelValue,
+				string(InstallVersionLabelKey):   "0.7.0",
+			},
+		},
+		"with install config name": {
+			configName: "openebs-install",
+			expectLabels: map[string]string{
+				string(InstallManagedByLabelKey):  InstallManagedByLabelValue,
+				string(InstallVersionLabelKey):    "0.7.0",
+				string(InstallConfigNameLabelKey): "openebs-install",
+			},
+		},
+		"existing labels are retained": {
+			labels: map[string]string{"app": "maya", string(InstallVersionLabelKey): "0.6.0"},
+			expectLabels: map[string]string{
+				"app":                            "maya",
+				string(InstallManagedByLabelKey): InstallManagedByLabelValue,
+				string(InstallVersionLabelKey):   "0.7.0",
+			},
+		},
+	}
+
+	defer os.Unsetenv(string(EnvKeyForInstallConfigName))
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(EnvKeyForInstallConfigName), mock.configName)
+			unstruct := fakeUnstructured("ConfigMap", "one")
+			unstruct.SetLabels(mock.labels)
+
+			got := updateUnstructuredOwnership(Install{Version: "0.7.0"})(unstruct).GetLabels()
+			if len(got) != len(mock.expectLabels) {
+				t.Fatalf("expected labels '%v': got '%v'", mock.expectLabels, got)
+			}
+			for key, value := range mock.expectLabels {
+				if got[key] != value {
+					t.Fatalf("expected label '%s' to be '%s': got '%s'", key, value, got[key])
+				}
+			}
+		})
+	}
+}
+
+func TestPruneWithoutInstallConfigName(t *testing.T) {
+	os.Unsetenv(string(EnvKeyForInstallConfigName))
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) {
+		return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+	})
+
+	tests := map[string]struct {
+		dryRun bool
+	}{
+		"dry run": {dryRun: true},
+		"prune":   {dryRun: false},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			i.resetErrors()
+			items, errs := i.Prune(context.Background(), mock.dryRun)
+			if len(items) != 0 {
+				t.Fatalf("expected nothing to be pruned: got '%v'", items)
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), "missing install config name") {
+				t.Fatalf("expected missing install config name error: got '%v'", errs)
+			}
+		})
+	}
+}
+
+func TestPruneAfterInstallWithoutInstallConfigName(t *testing.T) {
+	os.Unsetenv(string(EnvKeyForInstallConfigName))
+	i := &simpleInstaller{pruneAllowlist: DefaultPruneAllowlist()}
+	levels := [][]*unstructured.Unstructured{{fakeUnstructured("ConfigMap", "one")}}
+	report := newInstallReport([]InstallReportItem{{Kind: "ConfigMap", Name: "one", Action: InstallActionCreated}}, nil)
+
+	got := i.pruneAfterInstall(context.Background(), report, levels)
+	if !got.IsSuccess() || len(got.Errors) != 0 || len(i.errors) != 0 {
+		t.Fatalf("expected prune to be skipped without errors: got '%v'", got.Errors)
+	}
+	if len(got.Pruned) != 0 {
+		t.Fatalf("expected nothing to be pruned: got '%v'", got.Pruned)
+	}
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	// defaultReadinessTimeout is the maximum time to wait for a resource to
+	// be ready if not specified
+	defaultReadinessTimeout = 2 * time.Minute
+	// defaultReadinessInterval is the time between two readiness checks if
+	// not specified
+	defaultReadinessInterval = 2 * time.Second
+)
+
+// isWaitForReadiness returns true if install should wait for the applied
+// resources to be ready
+func isWaitForReadiness() bool {
+	waitForReadiness, _ := strconv.ParseBool(env.Get(string(EnvKeyForInstallWaitForReadiness)))
+	return waitForReadiness
+}
+
+// readinessTimeout returns the maximum time to wait for a resource to be
+// ready
+func readinessTimeout() time.Duration {
+	timeout, err := time.ParseDuration(env.Get(string(EnvKeyForInstallReadinessTimeout)))
+	if err != nil || timeout <= 0 {
+		return defaultReadinessTimeout
+	}
+	return timeout
+}
+
+// ReadinessChecker abstracts checking if a live resource is ready to be used
+type ReadinessChecker func(live *unstructured.Unstructured) (ready bool, err error)
+
+// IsCRDEstablished returns true if the given CustomResourceDefinition has
+// its Established condition set to True
+//
+// NOTE:
+//  This is an implementation of ReadinessChecker
+func IsCRDEstablished(live *unstructured.Unstructured) (bool, error) {
+	return hasConditionStatus(live, "Established", "True")
+}
+
+// IsDeploymentAvailable returns true if the given Deployment has observed
+// its latest generation and has all its desired replicas updated and
+// available
+//
+// NOTE:
+//  This is an implementation of ReadinessChecker
+func IsDeploymentAvailable(live *unstructured.Unstructured) (bool, error) {
+	observed, _, _ := unstructured.NestedInt64(live.Object, "status", "observedGeneration")
+	if observed < live.GetGeneration() {
+		return false, nil
+	}
+
+	desired, found, _ := unstructured.NestedInt64(live.Object, "spec", "replicas")
+	if !found {
+		// kubernetes defaults the replicas to 1
+		desired = 1
+	}
+
+	updated, _, _ := unstructured.NestedInt64(live.Object, "status", "updatedReplicas")
+	available, _, _ := unstructured.NestedInt64(live.Object, "status", "availableReplicas")
+	return updated >= desired && available >= desired, nil
+}
+
+// IsNamespaceActive returns true if the given Namespace is in Active phase
+//
+// NOTE:
+//  This is an implementation of ReadinessChecker
+func IsNamespaceActive(live *unstructured.Unstructured) (bool, error) {
+	phase, _, _ := unstructured.NestedString(live.Object, "status", "phase")
+	return phase == "Active", nil
+}
+
+// hasConditionStatus returns true if the given resource has the condition
+// of the given type set to the given status
+func hasConditionStatus(live *unstructured.Unstructured, conditionType, status string) (bool, error) {
+	conditions, _, err := unstructured.NestedSlice(live.Object, "status", "conditions")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check condition '%s' of '%s'", conditionType, live.GetName())
+	}
+
+	for _, condition := range conditions {
+		c, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if c["type"] == conditionType && c["status"] == status {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// NewJSONPathReadinessChecker returns a ReadinessChecker that considers a
+// resource as ready if any of the values found at the given JSONPath equals
+// the expected value e.g. path {.status.phase} with expected value Online
+func NewJSONPathReadinessChecker(path, expected string) (ReadinessChecker, error) {
+	// validate the path upfront
+	err := jsonpath.New("readiness").Parse(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid jsonpath '%s': failed to build readiness checker", path)
+	}
+
+	return func(live *unstructured.Unstructured) (bool, error) {
+		// a jsonpath instance is not safe for concurrent use
+		j := jsonpath.New("readiness").AllowMissingKeys(true)
+		err := j.Parse(path)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check jsonpath '%s' of '%s'", path, live.GetName())
+		}
+
+		results, err := j.FindResults(live.Object)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check jsonpath '%s' of '%s'", path, live.GetName())
+		}
+
+		for _, result := range results {
+			for _, value := range result {
+				if fmt.Sprint(value.Interface()) == expected {
+					return true, nil
+				}
+			}
+		}
+
+		return false, nil
+	}, nil
+}
+
+// WaitStrategy determines how the readiness of a resource is waited upon
+type WaitStrategy struct {
+	// Check returns true if the resource is ready
+	Check ReadinessChecker
+	// Timeout is the maximum time to wait for the resource to be ready
+	Timeout time.Duration
+	// Interval is the time between two readiness checks
+	Interval time.Duration
+}
+
+// WaitStrategies maps a kind to its wait strategy
+type WaitStrategies map[string]WaitStrategy
+
+// DefaultWaitStrategies returns the wait strategies of the kinds whose
+// readiness is understood by installer
+func DefaultWaitStrategies(timeout time.Duration) WaitStrategies {
+	return WaitStrategies{
+		"CustomResourceDefinition": {Check: IsCRDEstablished, Timeout: timeout},
+		"Deployment":               {Check: IsDeploymentAvailable, Timeout: timeout},
+		"Namespace":                {Check: IsNamespaceActive, Timeout: timeout},
+	}
+}
+
+// NewWaitStrategy returns the wait strategy corresponding to the given wait
+// options; the given timeout is used if the options do not specify one
+func NewWaitStrategy(options WaitOptions, timeout time.Duration) (strategy WaitStrategy, err error) {
+	if len(options.Kind) == 0 {
+		return strategy, fmt.Errorf("missing kind: failed to build wait strategy for jsonpath '%s'", options.JSONPath)
+	}
+
+	strategy.Check, err = NewJSONPathReadinessChecker(options.JSONPath, options.Value)
+	if err != nil {
+		return strategy, errors.Wrapf(err, "failed to build wait strategy for kind '%s'", options.Kind)
+	}
+
+	strategy.Timeout = timeout
+	if len(options.Timeout) != 0 {
+		strategy.Timeout, err = time.ParseDuration(options.Timeout)
+		if err != nil {
+			return strategy, errors.Wrapf(err, "invalid timeout: failed to build wait strategy for kind '%s'", options.Kind)
+		}
+	}
+
+	if len(options.Interval) != 0 {
+		strategy.Interval, err = time.ParseDuration(options.Interval)
+		if err != nil {
+			return strategy, errors.Wrapf(err, "invalid interval: failed to build wait strategy for kind '%s'", options.Kind)
+		}
+	}
+
+	return strategy, nil
+}
+
+// WithOptions returns a copy of these wait strategies along with the ones
+// built from the given wait options
+//
+// NOTE:
+//  A wait strategy built from the options overrides the one of the same kind
+func (s WaitStrategies) WithOptions(options []WaitOptions, timeout time.Duration) (WaitStrategies, error) {
+	strategies := WaitStrategies{}
+	for kind, strategy := range s {
+		strategies[kind] = strategy
+	}
+
+	for _, o := range options {
+		strategy, err := NewWaitStrategy(o, timeout)
+		if err != nil {
+			return nil, err
+		}
+		strategies[o.Kind] = strategy
+	}
+
+	return strategies, nil
+}
+
+// isReady returns true if all the given readiness report items are ready
+func isReady(items []ReadinessReportItem) bool {
+	for _, item := range items {
+		if !item.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadinessReportItem is the outcome of waiting for a single resource to be
+// ready
+type ReadinessReportItem struct {
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Ready     bool          `json:"ready"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// waitForReadiness waits till the given resource is ready as per the given
+// strategy or till the given context is done
+func waitForReadiness(ctx context.Context, unstruct *unstructured.Unstructured, strategy WaitStrategy) ReadinessReportItem {
+	item := ReadinessReportItem{
+		Kind:      unstruct.GetKind(),
+		Namespace: unstruct.GetNamespace(),
+		Name:      unstruct.GetName(),
+	}
+
+	timeout := strategy.Timeout
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+
+	interval := strategy.Interval
+	if interval <= 0 {
+		interval = defaultReadinessInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	get := k8s.NewResourceGetterWithContext(ctx, GroupVersionResourceFromGVK(unstruct), unstruct.GetNamespace())
+	start := time.Now()
+
+	var lastErr error
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		live, err := get(unstruct.GetName(), metav1.GetOptions{})
+		if err != nil {
+			// the resource may not be visible yet
+			lastErr = err
+			return false, nil
+		}
+
+		ready, err := strategy.Check(live)
+		lastErr = err
+		return ready && err == nil, nil
+	}, ctx.Done())
+
+	item.Duration = time.Since(start)
+	item.Ready = err == nil
+	if err != nil {
+		if lastErr != nil {
+			err = lastErr
+		}
+		item.Error = errors.Wrapf(err, "resource '%s' is not ready after %s", unstruct.GetName(), timeout).Error()
+	}
+
+	return item
+}
+
+// WaitForReadiness waits till all the given resources having a wait strategy
+// are ready
+//
+// NOTE:
+//  Resources are waited upon in the given order. Resources without a wait
+// strategy are skipped.
+func WaitForReadiness(list []*unstructured.Unstructured, strategies WaitStrategies) (items []ReadinessReportItem) {
+	return WaitForReadinessWithContext(context.Background(), list, strategies)
+}
+
+// WaitForReadinessWithContext waits till all the given resources having a
+// wait strategy are ready or till the given context is done
+//
+// NOTE:
+//  The resources that are waited upon after the context is done are
+// reported as not ready
+func WaitForReadinessWithContext(ctx context.Context, list []*unstructured.Unstructured, strategies WaitStrategies) (items []ReadinessReportItem) {
+	for _, unstruct := range list {
+		if unstruct == nil {
+			continue
+		}
+
+		strategy, found := strategies[unstruct.GetKind()]
+		if !found || strategy.Check == nil {
+			continue
+		}
+
+		items = append(items, waitForReadiness(ctx, unstruct, strategy))
+	}
+
+	return
+}
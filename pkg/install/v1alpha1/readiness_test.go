@@ -0,0 +1,271 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fakeLive returns an unstructured instance of the given kind with the given
+// object fields
+func fakeLive(kind string, fields map[string]interface{}) *unstructured.Unstructured {
+	unstruct := fakeUnstructured(kind, "live")
+	for key, value := range fields {
+		unstruct.Object[key] = value
+	}
+	return unstruct
+}
+
+func TestReadinessCheckers(t *testing.T) {
+	tests := map[string]struct {
+		check       ReadinessChecker
+		live        *unstructured.Unstructured
+		expectReady bool
+	}{
+		"established crd": {
+			check: IsCRDEstablished,
+			live: fakeLive("CustomResourceDefinition", map[string]interface{}{
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+					map[string]interface{}{"type": "Established", "status": "True"},
+				}},
+			}),
+			expectReady: true,
+		},
+		"crd that is not established": {
+			check: IsCRDEstablished,
+			live: fakeLive("CustomResourceDefinition", map[string]interface{}{
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Established", "status": "False"},
+				}},
+			}),
+		},
+		"crd without status": {
+			check: IsCRDEstablished,
+			live:  fakeLive("CustomResourceDefinition", nil),
+		},
+		"available deployment": {
+			check: IsDeploymentAvailable,
+			live: fakeLive("Deployment", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{"updatedReplicas": int64(2), "availableReplicas": int64(2)},
+			}),
+			expectReady: true,
+		},
+		"deployment with default replicas": {
+			check: IsDeploymentAvailable,
+			live: fakeLive("Deployment", map[string]interface{}{
+				"status": map[string]interface{}{"updatedReplicas": int64(1), "availableReplicas": int64(1)},
+			}),
+			expectReady: true,
+		},
+		"deployment with unavailable replicas": {
+			check: IsDeploymentAvailable,
+			live: fakeLive("Deployment", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{"updatedReplicas": int64(2), "availableReplicas": int64(1)},
+			}),
+		},
+		"deployment with unobserved generation": {
+			check: IsDeploymentAvailable,
+			live: fakeLive("Deployment", map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "live", "generation": int64(2)},
+				"status":   map[string]interface{}{"observedGeneration": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1)},
+			}),
+		},
+		"active namespace": {
+			check:       IsNamespaceActive,
+			live:        fakeLive("Namespace", map[string]interface{}{"status": map[string]interface{}{"phase": "Active"}}),
+			expectReady: true,
+		},
+		"terminating namespace": {
+			check: IsNamespaceActive,
+			live:  fakeLive("Namespace", map[string]interface{}{"status": map[string]interface{}{"phase": "Terminating"}}),
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			ready, err := mock.check(mock.live)
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if ready != mock.expectReady {
+				t.Fatalf("expected ready '%t': got '%t'", mock.expectReady, ready)
+			}
+		})
+	}
+}
+
+func TestNewJSONPathReadinessChecker(t *testing.T) {
+	live := fakeLive("CStorPool", map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase":    "Online",
+			"replicas": int64(3),
+			"pools":    []interface{}{map[string]interface{}{"phase": "Init"}, map[string]interface{}{"phase": "Healthy"}},
+		},
+	})
+
+	tests := map[string]struct {
+		path        string
+		expected    string
+		expectReady bool
+		expectErr   bool
+	}{
+		"matching string":          {path: "{.status.phase}", expected: "Online", expectReady: true},
+		"mismatching string":       {path: "{.status.phase}", expected: "Offline"},
+		"matching number":          {path: "{.status.replicas}", expected: "3", expectReady: true},
+		"any of the listed values": {path: "{.status.pools[*].phase}", expected: "Healthy", expectReady: true},
+		"missing key":              {path: "{.status.reason}", expected: "Online"},
+		"invalid path":             {path: "{.status.phase", expected: "Online", expectErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			check, err := NewJSONPathReadinessChecker(mock.path, mock.expected)
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if mock.expectErr {
+				return
+			}
+
+			ready, err := check(live)
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if ready != mock.expectReady {
+				t.Fatalf("expected ready '%t': got '%t'", mock.expectReady, ready)
+			}
+		})
+	}
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected time.Duration
+	}{
+		"not set":  {value: "", expected: defaultReadinessTimeout},
+		"valid":    {value: "30s", expected: 30 * time.Second},
+		"invalid":  {value: "soon", expected: defaultReadinessTimeout},
+		"negative": {value: "-1m", expected: defaultReadinessTimeout},
+	}
+
+	defer os.Unsetenv(string(EnvKeyForInstallReadinessTimeout))
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(EnvKeyForInstallReadinessTimeout), mock.value)
+			if got := readinessTimeout(); got != mock.expected {
+				t.Fatalf("expected timeout '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestWaitForReadinessWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	strategies := WaitStrategies{
+		"Namespace": {Check: IsNamespaceActive, Timeout: time.Second, Interval: 10 * time.Millisecond},
+		"Service":   {Timeout: time.Second},
+	}
+	list := []*unstructured.Unstructured{
+		fakeUnstructured("ConfigMap", "without-strategy"),
+		nil,
+		fakeUnstructured("Service", "without-check"),
+		fakeUnstructured("Namespace", "openebs"),
+	}
+
+	items := WaitForReadinessWithContext(ctx, list, strategies)
+	if len(items) != 1 {
+		t.Fatalf("expected readiness of only the namespace: got '%+v'", items)
+	}
+	if items[0].Kind != "Namespace" || items[0].Name != "openebs" {
+		t.Fatalf("expected readiness of namespace 'openebs': got '%+v'", items[0])
+	}
+	if items[0].Ready || len(items[0].Error) == 0 {
+		t.Fatalf("expected namespace to be not ready once context is done: got '%+v'", items[0])
+	}
+}
+
+func TestWaitStrategiesWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		options        []WaitOptions
+		expectTimeout  time.Duration
+		expectInterval time.Duration
+		expectReady    bool
+		expectErr      bool
+	}{
+		"jsonpath": {
+			options:       []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase}", Value: "Online"}},
+			expectTimeout: time.Minute,
+			expectReady:   true,
+		},
+		"jsonpath with timeout & interval": {
+			options:        []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase}", Value: "Online", Timeout: "5m", Interval: "5s"}},
+			expectTimeout:  5 * time.Minute,
+			expectInterval: 5 * time.Second,
+			expectReady:    true,
+		},
+		"jsonpath of other value": {
+			options:       []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase}", Value: "Offline"}},
+			expectTimeout: time.Minute,
+		},
+		"missing kind":     {options: []WaitOptions{{JSONPath: "{.status.phase}", Value: "Online"}}, expectErr: true},
+		"invalid jsonpath": {options: []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase", Value: "Online"}}, expectErr: true},
+		"invalid timeout":  {options: []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase}", Timeout: "soon"}}, expectErr: true},
+		"invalid interval": {options: []WaitOptions{{Kind: "CStorPool", JSONPath: "{.status.phase}", Interval: "often"}}, expectErr: true},
+	}
+
+	pool := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "CStorPool", "status": map[string]interface{}{"phase": "Online"}}}
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			defaults := DefaultWaitStrategies(time.Minute)
+			strategies, err := defaults.WithOptions(mock.options, time.Minute)
+			if mock.expectErr {
+				if err == nil {
+					t.Fatalf("expected error: got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+
+			if len(strategies) != len(defaults)+1 || len(defaults) != len(DefaultWaitStrategies(time.Minute)) {
+				t.Fatalf("expected defaults to be retained in a copy: got '%d' strategies from '%d' defaults", len(strategies), len(defaults))
+			}
+
+			strategy := strategies["CStorPool"]
+			if strategy.Timeout != mock.expectTimeout || strategy.Interval != mock.expectInterval {
+				t.Fatalf("expected timeout '%s' & interval '%s': got '%s' & '%s'", mock.expectTimeout, mock.expectInterval, strategy.Timeout, strategy.Interval)
+			}
+
+			ready, err := strategy.Check(pool)
+			if err != nil || ready != mock.expectReady {
+				t.Fatalf("expected ready '%t': got '%t' with error '%v'", mock.expectReady, ready, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RenderFormat is a typed string to represent the supported formats to
+// render the resources
+type RenderFormat string
+
+const (
+	// RenderFormatYAML renders the resources as a multi document YAML
+	RenderFormatYAML RenderFormat = "yaml"
+	// RenderFormatJSON renders the resources as a stream of JSON documents
+	RenderFormatJSON RenderFormat = "json"
+)
+
+// UnstructuredListRenderer abstracts rendering a list of unstructured
+// instances
+type UnstructuredListRenderer func(list []*unstructured.Unstructured) (rendered []byte, err error)
+
+// UnstructuredListRendererFor returns the renderer corresponding to the
+// provided format
+func UnstructuredListRendererFor(format RenderFormat) (UnstructuredListRenderer, error) {
+	switch format {
+	case RenderFormatYAML:
+		return RenderUnstructuredListAsYAML, nil
+	case RenderFormatJSON:
+		return RenderUnstructuredListAsJSON, nil
+	default:
+		return nil, fmt.Errorf("invalid format '%s': failed to get unstructured list renderer", format)
+	}
+}
+
+// RenderUnstructuredListAsJSON renders the list of unstructured instances as
+// a stream of JSON documents separated by new lines
+//
+// NOTE:
+//  This is an implementation of UnstructuredListRenderer
+func RenderUnstructuredListAsJSON(list []*unstructured.Unstructured) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, unstruct := range list {
+		if unstruct == nil {
+			continue
+		}
+
+		raw, err := unstruct.MarshalJSON()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render '%s' as json", unstruct.GetName())
+		}
+
+		// the encoded document may already end with a new line
+		buf.Write(bytes.TrimSpace(raw))
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderUnstructuredListAsYAML renders the list of unstructured instances as
+// a multi document YAML
+//
+// NOTE:
+//  This is an implementation of UnstructuredListRenderer
+func RenderUnstructuredListAsYAML(list []*unstructured.Unstructured) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, unstruct := range list {
+		if unstruct == nil {
+			continue
+		}
+
+		raw, err := unstruct.MarshalJSON()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render '%s' as yaml", unstruct.GetName())
+		}
+
+		doc, err := yaml.JSONToYAML(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to render '%s' as yaml", unstruct.GetName())
+		}
+
+		buf.WriteString("---\n")
+		buf.Write(doc)
+	}
+
+	return buf.Bytes(), nil
+}
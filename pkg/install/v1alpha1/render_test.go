@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUnstructuredListRendererFor(t *testing.T) {
+	list := []*unstructured.Unstructured{
+		fakeUnstructured("ConfigMap", "one"),
+		nil,
+		fakeUnstructured("ConfigMap", "two"),
+	}
+
+	tests := map[string]struct {
+		format    RenderFormat
+		expected  string
+		expectErr bool
+	}{
+		"yaml": {
+			format: RenderFormatYAML,
+			expected: "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: one\n  namespace: openebs\n" +
+				"---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: two\n  namespace: openebs\n",
+		},
+		"json": {
+			format: RenderFormatJSON,
+			expected: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"one","namespace":"openebs"}}` + "\n" +
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"two","namespace":"openebs"}}` + "\n",
+		},
+		"invalid format": {
+			format:    "toml",
+			expectErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			render, err := UnstructuredListRendererFor(mock.format)
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if mock.expectErr {
+				return
+			}
+
+			rendered, err := render(list)
+			if err != nil {
+				t.Fatalf("expected no error: got '%v'", err)
+			}
+			if string(rendered) != mock.expected {
+				t.Fatalf("expected rendered '%s': got '%s'", mock.expected, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderEmptyUnstructuredList(t *testing.T) {
+	for name, render := range map[string]UnstructuredListRenderer{
+		"yaml": RenderUnstructuredListAsYAML,
+		"json": RenderUnstructuredListAsJSON,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rendered, err := render(nil)
+			if err != nil || len(rendered) != 0 {
+				t.Fatalf("expected nothing to be rendered: got '%s' with error '%v'", rendered, err)
+			}
+		})
+	}
+}
+
+func TestRenderWithoutCluster(t *testing.T) {
+	missingRunTask := &Artifact{Doc: `
+apiVersion: openebs.io/v1alpha1
+kind: CASTemplate
+metadata:
+  name: fake-volume-create
+spec:
+  taskNamespace: openebs
+  run:
+    tasks:
+    - fake-missing-task
+`}
+
+	tests := map[string]struct {
+		install     Install
+		artifacts   *ArtifactList
+		expectError string
+	}{
+		"run tasks are not selected": {
+			install: Install{Version: "0.7.0", Include: []ArtifactSelector{{Kind: "CASTemplate"}}},
+		},
+		"run task is neither rendered nor skipped": {
+			install:     Install{Version: "0.7.0"},
+			artifacts:   &ArtifactList{Items: []*Artifact{missingRunTask}},
+			expectError: "missing run task 'fake-missing-task'",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := &InstallConfig{Spec: InstallConfigSpec{Install: []Install{mock.install}}}
+			i := NewSimpleInstaller(func(name string) (*InstallConfig, error) { return config, nil })
+			if mock.artifacts != nil {
+				i.artifactLister = func(version string) (ArtifactList, error) { return *mock.artifacts, nil }
+			}
+
+			rendered, errs := i.Render(RenderFormatYAML)
+			if len(mock.expectError) == 0 {
+				if len(errs) != 0 || len(rendered) == 0 {
+					t.Fatalf("expected resources to be rendered: got errors '%v'", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), mock.expectError) {
+				t.Fatalf("expected error '%s' without looking up the cluster: got '%v'", mock.expectError, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// InstallAction is a typed string to represent the action taken against a
+// resource during install
+type InstallAction string
+
+const (
+	// InstallActionCreated indicates the resource was created
+	InstallActionCreated InstallAction = InstallAction(k8s.ApplyActionCreated)
+	// InstallActionUpdated indicates the resource was updated
+	InstallActionUpdated InstallAction = InstallAction(k8s.ApplyActionUpdated)
+	// InstallActionUnchanged indicates the resource was already in its desired
+	// state
+	InstallActionUnchanged InstallAction = InstallAction(k8s.ApplyActionUnchanged)
+	// InstallActionFailed indicates the resource could not be applied
+	InstallActionFailed InstallAction = "failed"
+	// InstallActionNotAttempted indicates the resource was never applied
+	// since the install run was interrupted
+	InstallActionNotAttempted InstallAction = "not-attempted"
+)
+
+// InstallReportItem is the outcome of installing a single resource
+type InstallReportItem struct {
+	Group     string        `json:"group"`
+	Version   string        `json:"version"`
+	Resource  string        `json:"resource"`
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	Digest    string        `json:"digest,omitempty"`
+	Action    InstallAction `json:"action"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// newInstallReportItem returns a new report item for the given unstructured
+// instance
+func newInstallReportItem(unstruct *unstructured.Unstructured) InstallReportItem {
+	gvr := GroupVersionResourceFromGVK(unstruct)
+	digest, _ := DigestUnstructured(unstruct)
+
+	return InstallReportItem{
+		Group:     gvr.Group,
+		Version:   gvr.Version,
+		Resource:  gvr.Resource,
+		Kind:      unstruct.GetKind(),
+		Namespace: unstruct.GetNamespace(),
+		Name:      unstruct.GetName(),
+		Digest:    digest,
+	}
+}
+
+// notAttemptedInstallReportItem returns a new report item for the given
+// unstructured instance that was never applied
+func notAttemptedInstallReportItem(unstruct *unstructured.Unstructured) InstallReportItem {
+	item := newInstallReportItem(unstruct)
+	item.Action = InstallActionNotAttempted
+	return item
+}
+
+// InstallSummary has the counts of resources per install action
+type InstallSummary struct {
+	Total     int `json:"total"`
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+	// NotAttempted is the count of resources that were never applied since
+	// the install run was interrupted
+	NotAttempted int `json:"notAttempted,omitempty"`
+}
+
+// InstallReport is the outcome of an install run
+type InstallReport struct {
+	// Items has the outcome of every resource that was attempted to be
+	// installed
+	Items []InstallReportItem `json:"items"`
+	// Errors that are not specific to any resource e.g. failure to get the
+	// install config
+	Errors []string `json:"errors,omitempty"`
+	// Summary of this report
+	Summary InstallSummary `json:"summary"`
+	// Rollback has the outcome of every resource that was rolled back after a
+	// failed transactional install
+	Rollback []RollbackReportItem `json:"rollback,omitempty"`
+	// Readiness has the outcome of waiting for the installed resources to be
+	// ready
+	Readiness []ReadinessReportItem `json:"readiness,omitempty"`
+	// Pruned has the outcome of every resource that was deleted since it is no
+	// longer specified in the install config
+	Pruned []PruneReportItem `json:"pruned,omitempty"`
+	// Hooks has the outcome of every hook that was run
+	Hooks []HookReportItem `json:"hooks,omitempty"`
+	// Skipped has the artifacts that were not installed since they were not
+	// selected by the install config
+	Skipped []SkipReportItem `json:"skipped,omitempty"`
+}
+
+// newInstallReport returns a new install report based on the given items
+// and errors
+func newInstallReport(items []InstallReportItem, errs []error) (report InstallReport) {
+	report.Items = items
+
+	for _, err := range errs {
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+
+	for _, item := range items {
+		report.Summary.Total++
+		switch item.Action {
+		case InstallActionCreated:
+			report.Summary.Created++
+		case InstallActionUpdated:
+			report.Summary.Updated++
+		case InstallActionUnchanged:
+			report.Summary.Unchanged++
+		case InstallActionFailed:
+			report.Summary.Failed++
+		case InstallActionNotAttempted:
+			report.Summary.NotAttempted++
+		}
+	}
+
+	return
+}
+
+// IsSuccess returns true if all the resources were installed without any
+// errors
+func (r InstallReport) IsSuccess() bool {
+	if len(r.Errors) != 0 || r.Summary.Failed != 0 || r.Summary.NotAttempted != 0 {
+		return false
+	}
+
+	for _, item := range r.Readiness {
+		if !item.Ready {
+			return false
+		}
+	}
+
+	for _, item := range r.Pruned {
+		if item.Action == PruneActionFailed {
+			return false
+		}
+	}
+
+	for _, item := range r.Hooks {
+		if !item.Succeeded {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExitCode returns the process exit code corresponding to this report
+func (r InstallReport) ExitCode() int {
+	if r.IsSuccess() {
+		return 0
+	}
+	return 1
+}
+
+// JSON returns the JSON representation of this report
+func (r InstallReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Table returns the tabular representation of this report
+func (r InstallReport) Table() string {
+	var buf bytes.Buffer
+
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tNAMESPACE\tNAME\tACTION\tDURATION\tERROR")
+	for _, item := range r.Items {
+		resource := item.Resource
+		if len(item.Group) != 0 {
+			resource = item.Resource + "." + item.Group
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", resource, item.Namespace, item.Name, item.Action, item.Duration, item.Error)
+	}
+	w.Flush()
+
+	for _, err := range r.Errors {
+		fmt.Fprintf(&buf, "error: %s\n", err)
+	}
+
+	for _, item := range r.Readiness {
+		if !item.Ready {
+			fmt.Fprintf(&buf, "not ready: %s/%s %s: %s\n", item.Kind, item.Name, item.Namespace, item.Error)
+		}
+	}
+
+	for _, item := range r.Hooks {
+		fmt.Fprintf(&buf, "hook: %s %s/%s %s", item.Phase, item.Kind, item.Name, item.Namespace)
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, ": %s", item.Error)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	for _, item := range r.Pruned {
+		fmt.Fprintf(&buf, "pruned: %s %s/%s %s", item.Action, item.Kind, item.Name, item.Namespace)
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, ": %s", item.Error)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	for _, item := range r.Skipped {
+		fmt.Fprintf(&buf, "skipped: %s %s/%s: %s\n", item.Version, item.Kind, item.Name, item.Reason)
+	}
+
+	for _, item := range r.Rollback {
+		fmt.Fprintf(&buf, "rollback: %s %s/%s %s", item.Action, item.Kind, item.Name, item.Namespace)
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, ": %s", item.Error)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	fmt.Fprintf(&buf, "\n%d resources: %d created, %d updated, %d unchanged, %d failed",
+		r.Summary.Total, r.Summary.Created, r.Summary.Updated, r.Summary.Unchanged, r.Summary.Failed)
+	if r.Summary.NotAttempted != 0 {
+		fmt.Fprintf(&buf, ", %d not attempted", r.Summary.NotAttempted)
+	}
+	fmt.Fprintln(&buf)
+
+	return buf.String()
+}
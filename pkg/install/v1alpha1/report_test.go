@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewInstallReport(t *testing.T) {
+	items := []InstallReportItem{
+		{Kind: "ConfigMap", Name: "one", Action: InstallActionCreated},
+		{Kind: "ConfigMap", Name: "two", Action: InstallActionCreated},
+		{Kind: "ConfigMap", Name: "three", Action: InstallActionUpdated},
+		{Kind: "ConfigMap", Name: "four", Action: InstallActionUnchanged},
+		{Kind: "ConfigMap", Name: "five", Action: InstallActionFailed},
+		{Kind: "ConfigMap", Name: "six", Action: InstallActionNotAttempted},
+	}
+
+	report := newInstallReport(items, []error{nil, fmt.Errorf("boom")})
+	expected := InstallSummary{Total: 6, Created: 2, Updated: 1, Unchanged: 1, Failed: 1, NotAttempted: 1}
+	if report.Summary != expected {
+		t.Fatalf("expected summary '%+v': got '%+v'", expected, report.Summary)
+	}
+	if len(report.Errors) != 1 || report.Errors[0] != "boom" {
+		t.Fatalf("expected only non nil errors: got '%v'", report.Errors)
+	}
+}
+
+func TestInstallReportIsSuccess(t *testing.T) {
+	created := []InstallReportItem{{Kind: "ConfigMap", Name: "one", Action: InstallActionCreated}}
+
+	tests := map[string]struct {
+		report        InstallReport
+		expectSuccess bool
+	}{
+		"empty report": {
+			report:        newInstallReport(nil, nil),
+			expectSuccess: true,
+		},
+		"created resources": {
+			report:        newInstallReport(created, nil),
+			expectSuccess: true,
+		},
+		"errors": {
+			report: newInstallReport(created, []error{fmt.Errorf("boom")}),
+		},
+		"failed resources": {
+			report: newInstallReport([]InstallReportItem{{Action: InstallActionFailed}}, nil),
+		},
+		"not attempted resources": {
+			report: newInstallReport([]InstallReportItem{{Action: InstallActionNotAttempted}}, nil),
+		},
+		"resources that are not ready": {
+			report: InstallReport{Readiness: []ReadinessReportItem{{Ready: true}, {Ready: false}}},
+		},
+		"failed prune": {
+			report: InstallReport{Pruned: []PruneReportItem{{Action: PruneActionFailed}}},
+		},
+		"failed hooks": {
+			report: InstallReport{Hooks: []HookReportItem{{Succeeded: false}}},
+		},
+		"skipped artifacts": {
+			report:        InstallReport{Skipped: []SkipReportItem{{Kind: "RunTask", Name: "one"}}},
+			expectSuccess: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := mock.report.IsSuccess(); got != mock.expectSuccess {
+				t.Fatalf("expected success '%t': got '%t'", mock.expectSuccess, got)
+			}
+			expectExitCode := 1
+			if mock.expectSuccess {
+				expectExitCode = 0
+			}
+			if got := mock.report.ExitCode(); got != expectExitCode {
+				t.Fatalf("expected exit code '%d': got '%d'", expectExitCode, got)
+			}
+		})
+	}
+}
+
+func TestInstallReportTable(t *testing.T) {
+	report := newInstallReport([]InstallReportItem{
+		{Group: "openebs.io", Resource: "castemplates", Kind: "CASTemplate", Name: "cast", Action: InstallActionCreated},
+		{Resource: "configmaps", Kind: "ConfigMap", Namespace: "openebs", Name: "one", Action: InstallActionFailed, Error: "boom"},
+	}, []error{fmt.Errorf("failed to get install config")})
+	report.Skipped = []SkipReportItem{{Version: "0.7.0", Kind: "RunTask", Name: "two", Reason: notIncludedSkipReason}}
+	report.Rollback = []RollbackReportItem{{Kind: "CASTemplate", Name: "cast", Action: RollbackActionDeleted}}
+
+	table := report.Table()
+	for _, expected := range []string{
+		"RESOURCE",
+		"castemplates.openebs.io",
+		"configmaps",
+		"error: failed to get install config\n",
+		"skipped: 0.7.0 RunTask/two: " + notIncludedSkipReason + "\n",
+		"rollback: deleted CASTemplate/cast",
+		"2 resources: 1 created, 0 updated, 0 unchanged, 1 failed\n",
+	} {
+		if !strings.Contains(table, expected) {
+			t.Fatalf("expected table to contain '%s': got '%s'", expected, table)
+		}
+	}
+
+	if strings.Contains(table, "not attempted") {
+		t.Fatalf("expected no not attempted count without such resources: got '%s'", table)
+	}
+}
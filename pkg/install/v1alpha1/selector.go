@@ -0,0 +1,294 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// CASEngine is a typed string to represent the storage engine an artifact
+// belongs to
+type CASEngine string
+
+const (
+	// CASEngineCStor represents the cstor storage engine
+	CASEngineCStor CASEngine = "cstor"
+	// CASEngineJiva represents the jiva storage engine
+	CASEngineJiva CASEngine = "jiva"
+)
+
+// isValidCASEngine returns true if the given engine is understood by
+// installer
+func isValidCASEngine(engine CASEngine) bool {
+	return engine == CASEngineCStor || engine == CASEngineJiva
+}
+
+// WithCASEngineListUpdater updates the given list of artifacts with the
+// given storage engine
+func WithCASEngineListUpdater(engine CASEngine, given []*Artifact) (updated []*Artifact) {
+	for _, artifact := range given {
+		artifact.Engine = engine
+		updated = append(updated, artifact)
+	}
+	return
+}
+
+// ArtifactSelector selects the artifacts that match all of its specified
+// fields
+type ArtifactSelector struct {
+	// Engine is the storage engine of the artifact e.g. cstor or jiva
+	Engine CASEngine `json:"engine,omitempty"`
+	// Kind of the artifact e.g. CASTemplate or RunTask; matched case
+	// insensitively
+	Kind string `json:"kind,omitempty"`
+	// Name is a glob pattern on the name of the artifact e.g.
+	// cstor-volume-create-*
+	Name string `json:"name,omitempty"`
+}
+
+// String returns the selector as comma separated key value pairs
+func (s ArtifactSelector) String() string {
+	var pairs []string
+	if len(s.Engine) != 0 {
+		pairs = append(pairs, "engine="+string(s.Engine))
+	}
+	if len(s.Kind) != 0 {
+		pairs = append(pairs, "kind="+s.Kind)
+	}
+	if len(s.Name) != 0 {
+		pairs = append(pairs, "name="+s.Name)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// validate returns error if the selector is empty or has an invalid field
+func (s ArtifactSelector) validate() error {
+	if len(s.Engine) == 0 && len(s.Kind) == 0 && len(s.Name) == 0 {
+		return fmt.Errorf("empty artifact selector: at least one of engine, kind or name is required")
+	}
+
+	if len(s.Engine) != 0 && !isValidCASEngine(CASEngine(strings.ToLower(string(s.Engine)))) {
+		return fmt.Errorf("invalid artifact selector '%s': unsupported engine '%s'", s, s.Engine)
+	}
+
+	if _, err := path.Match(s.Name, ""); err != nil {
+		return errors.Wrapf(err, "invalid artifact selector '%s': invalid name pattern '%s'", s, s.Name)
+	}
+
+	return nil
+}
+
+// matches returns true if the given artifact metadata matches all the
+// specified fields of this selector
+func (s ArtifactSelector) matches(meta artifactMeta) bool {
+	if len(s.Engine) != 0 && !strings.EqualFold(string(s.Engine), string(meta.Engine)) {
+		return false
+	}
+
+	if len(s.Kind) != 0 && !strings.EqualFold(s.Kind, meta.Kind) {
+		return false
+	}
+
+	if len(s.Name) != 0 {
+		matched, _ := path.Match(s.Name, meta.Name)
+		return matched
+	}
+
+	return true
+}
+
+// artifactMeta has the properties of an artifact that can be selected upon
+type artifactMeta struct {
+	Engine CASEngine
+	Kind   string
+	Name   string
+}
+
+// artifactKindsByResource maps the resource an artifact is registered as to
+// the kind the artifact is selected by
+//
+// NOTE:
+//  Run tasks of some versions are documented as config maps
+var artifactKindsByResource = map[string]string{
+	"castemplates": "CASTemplate",
+	"runtasks":     "RunTask",
+}
+
+// artifactMetaFrom returns the selectable properties of the given artifact
+//
+// NOTE:
+//  The kind of a registered artifact is derived from its resource & falls
+// back to the kind found in its document
+func artifactMetaFrom(artifact *Artifact) (meta artifactMeta, err error) {
+	doc := struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}{}
+
+	err = yaml.Unmarshal([]byte(artifact.Doc), &doc)
+	if err != nil {
+		return meta, errors.Wrap(err, "failed to read artifact metadata")
+	}
+
+	meta = artifactMeta{Engine: artifact.Engine, Kind: doc.Kind, Name: doc.Metadata.Name}
+	if kind, found := artifactKindsByResource[artifact.GroupVersionResource.Resource]; found {
+		meta.Kind = kind
+	}
+	return meta, nil
+}
+
+// SkipReportItem is the outcome of an artifact that was not installed since
+// it was not selected by the install config
+type SkipReportItem struct {
+	Version string    `json:"version"`
+	Engine  CASEngine `json:"engine,omitempty"`
+	Kind    string    `json:"kind"`
+	Name    string    `json:"name"`
+	Reason  string    `json:"reason"`
+}
+
+// ArtifactListSelector abstracts selecting the artifacts of a list
+type ArtifactListSelector func(list ArtifactList) (selected ArtifactList, skipped []SkipReportItem, errs []error)
+
+// SelectArtifactListFor returns the artifact list selector that selects the
+// artifacts as per the include & exclude selectors of the given install
+//
+// NOTE:
+//  An artifact is selected if it matches any of the include selectors & none
+// of the exclude selectors. All the artifacts are included if there are no
+// include selectors.
+//
+// NOTE:
+//  Run tasks referred to by the selected CASTemplates are selected as well
+// unless they match an exclude selector. Excluded run tasks are expected to
+// be managed outside of this install.
+//
+// NOTE:
+//  Nothing is selected if any of the selectors is invalid
+func SelectArtifactListFor(install Install) ArtifactListSelector {
+	return func(list ArtifactList) (selected ArtifactList, skipped []SkipReportItem, errs []error) {
+		for _, selector := range append(append([]ArtifactSelector{}, install.Include...), install.Exclude...) {
+			if err := selector.validate(); err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to select artifacts for version '%s'", install.Version))
+			}
+		}
+		if len(errs) != 0 {
+			return
+		}
+
+		metas := make([]artifactMeta, len(list.Items))
+		reasons := make([]string, len(list.Items))
+		referred := map[string]bool{}
+		for idx, artifact := range list.Items {
+			meta, err := artifactMetaFrom(artifact)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to select artifacts for version '%s'", install.Version))
+				continue
+			}
+
+			metas[idx] = meta
+			reasons[idx] = skipReason(meta, install.Include, install.Exclude)
+			if len(reasons[idx]) != 0 || !strings.EqualFold(meta.Kind, "CASTemplate") {
+				continue
+			}
+
+			refs, err := artifactReferences(artifact)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to select artifacts for version '%s'", install.Version))
+				continue
+			}
+			for _, ref := range refs {
+				referred[ref] = true
+			}
+		}
+		if len(errs) != 0 {
+			return
+		}
+
+		for idx, artifact := range list.Items {
+			meta, reason := metas[idx], reasons[idx]
+			// a referred run task is selected unless it is excluded
+			if reason == notIncludedSkipReason && strings.EqualFold(meta.Kind, "RunTask") && referred[meta.Name] {
+				reason = skipReason(meta, nil, install.Exclude)
+			}
+
+			if len(reason) == 0 {
+				selected.Items = append(selected.Items, artifact)
+				continue
+			}
+
+			skipped = append(skipped, SkipReportItem{
+				Version: install.Version,
+				Engine:  meta.Engine,
+				Kind:    meta.Kind,
+				Name:    meta.Name,
+				Reason:  reason,
+			})
+		}
+
+		return
+	}
+}
+
+// notIncludedSkipReason is the reason an artifact that does not match any of
+// the include selectors is skipped
+const notIncludedSkipReason = "not matched by any include selector"
+
+// artifactReferences returns the names of the run tasks referred to by the
+// given CASTemplate artifact
+func artifactReferences(artifact *Artifact) (refs []string, err error) {
+	casTemplate := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(artifact.Doc), &casTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read run tasks referred to by cas template")
+	}
+
+	return casTemplateReferences(&unstructured.Unstructured{Object: casTemplate}), nil
+}
+
+// skipReason returns the reason the artifact corresponding to the given
+// metadata is not selected; empty if it is selected
+func skipReason(meta artifactMeta, include, exclude []ArtifactSelector) string {
+	if len(include) != 0 {
+		var included bool
+		for _, selector := range include {
+			if selector.matches(meta) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return notIncludedSkipReason
+		}
+	}
+
+	for _, selector := range exclude {
+		if selector.matches(meta) {
+			return fmt.Sprintf("matched by exclude selector '%s'", selector)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectArtifactListFor(t *testing.T) {
+	all := RegisteredArtifactsFor070()
+	cstor := len(CstorVolumeArtifactsFor070().Items)
+	jiva := len(JivaVolumeArtifactsFor070().Items)
+
+	tests := map[string]struct {
+		include        []ArtifactSelector
+		exclude        []ArtifactSelector
+		expectSelected int
+		expectErr      bool
+	}{
+		"no selectors": {
+			expectSelected: cstor + jiva,
+		},
+		"include jiva engine": {
+			include:        []ArtifactSelector{{Engine: CASEngineJiva}},
+			expectSelected: jiva,
+		},
+		"exclude cstor engine in upper case": {
+			exclude:        []ArtifactSelector{{Engine: "CSTOR"}},
+			expectSelected: jiva,
+		},
+		"include jiva cas templates along with their run tasks": {
+			include: []ArtifactSelector{{Engine: CASEngineJiva, Kind: "castemplate"}},
+			// two of the jiva run tasks are not referred to by any cas template
+			expectSelected: jiva - 2,
+		},
+		"include jiva cas templates excluding their run tasks": {
+			include:        []ArtifactSelector{{Engine: CASEngineJiva, Kind: "castemplate"}},
+			exclude:        []ArtifactSelector{{Kind: "RunTask"}},
+			expectSelected: 4,
+		},
+		"include by name pattern excluding a single artifact": {
+			include:        []ArtifactSelector{{Kind: "CASTemplate", Name: "cstor-volume-*-default-0.7.0"}},
+			exclude:        []ArtifactSelector{{Name: "cstor-volume-list-default-0.7.0"}},
+			expectSelected: 22,
+		},
+		"exclude everything": {
+			exclude:        []ArtifactSelector{{Kind: "CASTemplate"}, {Kind: "RunTask"}},
+			expectSelected: 0,
+		},
+		"empty selector": {
+			include:   []ArtifactSelector{{}},
+			expectErr: true,
+		},
+		"unsupported engine": {
+			exclude:   []ArtifactSelector{{Engine: "mayastor"}},
+			expectErr: true,
+		},
+		"invalid name pattern": {
+			include:   []ArtifactSelector{{Name: "cstor-["}},
+			expectErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			install := Install{Version: "0.7.0", Include: mock.include, Exclude: mock.exclude}
+			selected, skipped, errs := SelectArtifactListFor(install)(all)
+			if mock.expectErr != (len(errs) != 0) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, errs)
+			}
+			if mock.expectErr {
+				return
+			}
+
+			if len(selected.Items) != mock.expectSelected {
+				t.Fatalf("expected '%d' selected artifacts: got '%d'", mock.expectSelected, len(selected.Items))
+			}
+			if len(selected.Items)+len(skipped) != len(all.Items) {
+				t.Fatalf("expected '%d' skipped artifacts: got '%d'", len(all.Items)-len(selected.Items), len(skipped))
+			}
+			for _, item := range skipped {
+				if item.Version != "0.7.0" || len(item.Name) == 0 || len(item.Reason) == 0 {
+					t.Fatalf("expected skipped artifact with version, name and reason: got '%+v'", item)
+				}
+			}
+		})
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	meta := artifactMeta{Engine: CASEngineCStor, Kind: "RunTask", Name: "cstor-volume-create-output-default-0.7.0"}
+
+	tests := map[string]struct {
+		include      []ArtifactSelector
+		exclude      []ArtifactSelector
+		expectReason string
+	}{
+		"selected": {
+			include: []ArtifactSelector{{Engine: CASEngineCStor}},
+		},
+		"not included": {
+			include:      []ArtifactSelector{{Engine: CASEngineJiva}},
+			expectReason: "not matched by any include selector",
+		},
+		"excluded": {
+			exclude:      []ArtifactSelector{{Kind: "RunTask", Name: "*-output-*"}},
+			expectReason: "matched by exclude selector 'kind=RunTask,name=*-output-*'",
+		},
+		"exclude needs all fields to match": {
+			exclude: []ArtifactSelector{{Engine: CASEngineJiva, Kind: "RunTask"}},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := skipReason(meta, mock.include, mock.exclude); got != mock.expectReason {
+				t.Fatalf("expected reason '%s': got '%s'", mock.expectReason, got)
+			}
+		})
+	}
+}
+
+func TestInstallUnstructuredListSkipped(t *testing.T) {
+	i := NewSimpleInstaller(nil)
+	install := Install{Version: "0.7.0", Exclude: []ArtifactSelector{{Engine: CASEngineCStor}}}
+
+	unstructs := i.installUnstructuredList(install)
+	if len(i.errors) != 0 {
+		t.Fatalf("expected no error: got '%v'", i.errors)
+	}
+
+	for _, unstruct := range unstructs {
+		if strings.HasPrefix(unstruct.GetName(), "cstor-") {
+			t.Fatalf("expected cstor artifacts to be skipped: got '%s'", unstruct.GetName())
+		}
+	}
+
+	if len(i.skipped) != len(CstorVolumeArtifactsFor070().Items) {
+		t.Fatalf("expected '%d' skipped artifacts: got '%d'", len(CstorVolumeArtifactsFor070().Items), len(i.skipped))
+	}
+}
+
+func TestSelectArtifactListForReferredRunTasks(t *testing.T) {
+	tests := map[string]struct {
+		exclude             []ArtifactSelector
+		expectExcludedNames []string
+	}{
+		"include cas templates": {},
+		"include cas templates excluding a run task": {
+			exclude:             []ArtifactSelector{{Kind: "RunTask", Name: "jiva-volume-create-getstorageclass-default-0.7.0"}},
+			expectExcludedNames: []string{"jiva-volume-create-getstorageclass-default-0.7.0"},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			install := Install{Version: "0.7.0", Include: []ArtifactSelector{{Kind: "CASTemplate"}}, Exclude: mock.exclude}
+			selected, skipped, errs := SelectArtifactListFor(install)(RegisteredArtifactsFor070())
+			if len(errs) != 0 {
+				t.Fatalf("expected no error: got '%v'", errs)
+			}
+
+			excluded := map[string]bool{}
+			for _, name := range mock.expectExcludedNames {
+				excluded[name] = true
+			}
+
+			names := map[string]bool{}
+			var refs []string
+			for _, artifact := range selected.Items {
+				meta, err := artifactMetaFrom(artifact)
+				if err != nil {
+					t.Fatalf("expected no error: got '%v'", err)
+				}
+				names[meta.Name] = true
+				if meta.Kind != "CASTemplate" {
+					continue
+				}
+
+				artifactRefs, err := artifactReferences(artifact)
+				if err != nil {
+					t.Fatalf("expected no error: got '%v'", err)
+				}
+				refs = append(refs, artifactRefs...)
+			}
+
+			if len(refs) == 0 {
+				t.Fatalf("expected run tasks referred to by cas templates: got none")
+			}
+			for _, ref := range refs {
+				if names[ref] == excluded[ref] {
+					t.Fatalf("expected run task '%s' to be selected '%t': got '%t'", ref, !excluded[ref], names[ref])
+				}
+			}
+
+			for _, item := range skipped {
+				if item.Kind == "RunTask" && !excluded[item.Name] && item.Reason != notIncludedSkipReason {
+					t.Fatalf("expected run task '%s' to be skipped as not included: got '%s'", item.Name, item.Reason)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// InstallAnnotationKey is a typed string to represent the annotations that
+// are set against the install config to report the state of install
+type InstallAnnotationKey string
+
+const (
+	// InstallStatusAnnotationKey is the annotation that has the outcome of the
+	// last install run i.e. succeeded or failed
+	InstallStatusAnnotationKey InstallAnnotationKey = "install.openebs.io/status"
+	// InstallSummaryAnnotationKey is the annotation that has the counts of
+	// resources per install action of the last install run
+	InstallSummaryAnnotationKey InstallAnnotationKey = "install.openebs.io/summary"
+	// InstallErrorAnnotationKey is the annotation that has the errors of the
+	// last install run; it is removed once install succeeds
+	InstallErrorAnnotationKey InstallAnnotationKey = "install.openebs.io/error"
+	// InstallObservedDigestAnnotationKey is the annotation that has the digest
+	// of the install config specs used by the last install run
+	InstallObservedDigestAnnotationKey InstallAnnotationKey = "install.openebs.io/observed-digest"
+	// InstallLastRunTimeAnnotationKey is the annotation that has the time of
+	// the last install run
+	InstallLastRunTimeAnnotationKey InstallAnnotationKey = "install.openebs.io/last-run-time"
+	// InstallConditionsAnnotationKey is the annotation that has the JSON
+	// encoded install conditions
+	InstallConditionsAnnotationKey InstallAnnotationKey = "install.openebs.io/conditions"
+)
+
+// InstallConditionType is a typed string to represent the aspects of the
+// install state
+type InstallConditionType string
+
+const (
+	// InstallConditionReady is true when the last install run succeeded
+	InstallConditionReady InstallConditionType = "Ready"
+	// InstallConditionProgressing is true while an install run is in progress
+	InstallConditionProgressing InstallConditionType = "Progressing"
+	// InstallConditionDegraded is true when the last install run failed
+	InstallConditionDegraded InstallConditionType = "Degraded"
+)
+
+// InstallCondition is the state of a single aspect of install
+type InstallCondition struct {
+	Type               InstallConditionType   `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime"`
+}
+
+// InstallConditions is the list of install conditions
+type InstallConditions []InstallCondition
+
+// Get returns the condition of the given type
+func (c InstallConditions) Get(conditionType InstallConditionType) (InstallCondition, bool) {
+	for _, condition := range c {
+		if condition.Type == conditionType {
+			return condition, true
+		}
+	}
+	return InstallCondition{}, false
+}
+
+// Set adds or replaces the condition of the same type
+//
+// NOTE:
+//  The last transition time is retained if the status does not change
+func (c InstallConditions) Set(condition InstallCondition) InstallConditions {
+	for idx, existing := range c {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		c[idx] = condition
+		return c
+	}
+	return append(c, condition)
+}
+
+// newInstallCondition returns a new install condition transitioned now
+func newInstallCondition(conditionType InstallConditionType, status corev1.ConditionStatus, reason, message string) InstallCondition {
+	return InstallCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+// installConditionsFrom returns the install conditions set as annotation of
+// the given install config
+func installConditionsFrom(cm *corev1.ConfigMap) (conditions InstallConditions) {
+	raw := cm.Annotations[string(InstallConditionsAnnotationKey)]
+	if len(strings.TrimSpace(raw)) == 0 {
+		return
+	}
+
+	err := json.Unmarshal([]byte(raw), &conditions)
+	if err != nil {
+		// conditions that can not be decoded are replaced
+		return nil
+	}
+	return
+}
+
+// InstallEventReason is a typed string to represent the reason of an event
+// recorded against the install config
+type InstallEventReason string
+
+const (
+	// InstallEventReasonInstalling indicates an install run has started
+	InstallEventReasonInstalling InstallEventReason = "Installing"
+	// InstallEventReasonInstalled indicates an install run succeeded
+	InstallEventReasonInstalled InstallEventReason = "Installed"
+	// InstallEventReasonInstallFailed indicates an install run failed
+	InstallEventReasonInstallFailed InstallEventReason = "InstallFailed"
+	// InstallEventReasonArtifactFailed indicates an artifact could not be
+	// installed
+	InstallEventReasonArtifactFailed InstallEventReason = "ArtifactFailed"
+	// InstallEventReasonHookFailed indicates a hook failed
+	InstallEventReasonHookFailed InstallEventReason = "HookFailed"
+	// InstallEventReasonNotReady indicates an installed resource did not
+	// become ready
+	InstallEventReasonNotReady InstallEventReason = "NotReady"
+	// InstallEventReasonRolledBack indicates a failed install was rolled back
+	InstallEventReasonRolledBack InstallEventReason = "RolledBack"
+	// InstallEventReasonPruned indicates resources that are no longer
+	// specified in the install config were deleted
+	InstallEventReasonPruned InstallEventReason = "Pruned"
+)
+
+// maxFailureEvents is the maximum number of per artifact failure events
+// recorded for an install run
+const maxFailureEvents = 10
+
+// installerEventSource is the component that records install events
+const installerEventSource = "openebs-installer"
+
+// installEventRecorder is the event recorder shared by every install status
+// of this process
+//
+// NOTE:
+//  An event is created before the recorder returns so that no event is lost
+// if the process exits right after an install run
+var installEventRecorder = k8s.NewEventRecorder(installerEventSource)
+
+// installEvent is an event to be recorded against the install config
+type installEvent struct {
+	eventType string
+	reason    InstallEventReason
+	message   string
+}
+
+// installEventsFor returns the events that describe the given install run
+func installEventsFor(versions []string, report InstallReport) (events []installEvent) {
+	applied := report.Summary.Total - report.Summary.Failed - report.Summary.NotAttempted
+	if report.IsSuccess() {
+		events = append(events, installEvent{corev1.EventTypeNormal, InstallEventReasonInstalled,
+			fmt.Sprintf("Installed version %s: %d objects applied", strings.Join(versions, ", "), applied)})
+	} else {
+		events = append(events, installEvent{corev1.EventTypeWarning, InstallEventReasonInstallFailed,
+			fmt.Sprintf("Failed to install version %s: %d objects applied, %d failed, %d not attempted",
+				strings.Join(versions, ", "), applied, report.Summary.Failed, report.Summary.NotAttempted)})
+	}
+
+	var failures []installEvent
+	for _, item := range report.Items {
+		if item.Action == InstallActionFailed {
+			failures = append(failures, installEvent{corev1.EventTypeWarning, InstallEventReasonArtifactFailed,
+				fmt.Sprintf("Failed to apply %s '%s': %s", item.Kind, item.Name, item.Error)})
+		}
+	}
+
+	for _, item := range report.Hooks {
+		if !item.Succeeded {
+			failures = append(failures, installEvent{corev1.EventTypeWarning, InstallEventReasonHookFailed,
+				fmt.Sprintf("Failed to run %s hook %s '%s': %s", item.Phase, item.Kind, item.Name, item.Error)})
+		}
+	}
+
+	for _, item := range report.Readiness {
+		if !item.Ready {
+			failures = append(failures, installEvent{corev1.EventTypeWarning, InstallEventReasonNotReady,
+				fmt.Sprintf("%s '%s' is not ready: %s", item.Kind, item.Name, item.Error)})
+		}
+	}
+
+	if len(failures) > maxFailureEvents {
+		failures = failures[:maxFailureEvents]
+	}
+	events = append(events, failures...)
+
+	if len(report.Rollback) != 0 {
+		events = append(events, installEvent{corev1.EventTypeNormal, InstallEventReasonRolledBack,
+			fmt.Sprintf("Rolled back %d objects", len(report.Rollback))})
+	}
+
+	if len(report.Pruned) != 0 {
+		events = append(events, installEvent{corev1.EventTypeNormal, InstallEventReasonPruned,
+			fmt.Sprintf("Pruned %d objects", len(report.Pruned))})
+	}
+
+	return
+}
+
+// installConditionsFor returns the conditions that describe the given
+// install run
+func installConditionsFor(report InstallReport) []InstallCondition {
+	if report.IsSuccess() {
+		return []InstallCondition{
+			newInstallCondition(InstallConditionReady, corev1.ConditionTrue, "InstallSucceeded", "all the artifacts are installed"),
+			newInstallCondition(InstallConditionProgressing, corev1.ConditionFalse, "InstallCompleted", ""),
+			newInstallCondition(InstallConditionDegraded, corev1.ConditionFalse, "InstallSucceeded", ""),
+		}
+	}
+
+	message := strings.Join(installReportErrors(report), "; ")
+	return []InstallCondition{
+		newInstallCondition(InstallConditionReady, corev1.ConditionFalse, "InstallFailed", message),
+		newInstallCondition(InstallConditionProgressing, corev1.ConditionFalse, "InstallCompleted", ""),
+		newInstallCondition(InstallConditionDegraded, corev1.ConditionTrue, "InstallFailed", message),
+	}
+}
+
+// installStatusAnnotations returns the annotations that represent the given
+// install report
+//
+// NOTE:
+//  An annotation with empty value is meant to be removed
+func installStatusAnnotations(report InstallReport) map[string]string {
+	status := InstallOutcomeSucceeded
+	if !report.IsSuccess() {
+		status = InstallOutcomeFailed
+	}
+
+	return map[string]string{
+		string(InstallStatusAnnotationKey): string(status),
+		string(InstallSummaryAnnotationKey): fmt.Sprintf("%d created, %d updated, %d unchanged, %d failed",
+			report.Summary.Created, report.Summary.Updated, report.Summary.Unchanged, report.Summary.Failed),
+		string(InstallErrorAnnotationKey):       strings.Join(installReportErrors(report), "; "),
+		string(InstallLastRunTimeAnnotationKey): time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// installReportErrors returns all the errors of the given install report
+func installReportErrors(report InstallReport) (errs []string) {
+	errs = append(errs, report.Errors...)
+	for _, item := range report.Items {
+		if len(item.Error) != 0 {
+			errs = append(errs, item.Error)
+		}
+	}
+	for _, item := range report.Hooks {
+		if len(item.Error) != 0 {
+			errs = append(errs, item.Error)
+		}
+	}
+	for _, item := range report.Readiness {
+		if len(item.Error) != 0 {
+			errs = append(errs, item.Error)
+		}
+	}
+	return
+}
+
+// InstallStatusRecorder abstracts recording the state of install against
+// the install config
+type InstallStatusRecorder interface {
+	// Progressing records that an install run has started
+	Progressing(ctx context.Context) error
+	// Record records the outcome of an install run
+	Record(ctx context.Context, config *InstallConfig, report InstallReport) error
+}
+
+// configMapInstallStatus records the state of install as annotations as well
+// as events of the install config ConfigMap
+//
+// NOTE:
+//  This is an implementation of InstallStatusRecorder. The ConfigMap clients
+// are built for every call so that they honour the context of the caller.
+type configMapInstallStatus struct {
+	name      string
+	namespace string
+	getter    func(ctx context.Context, namespace string) k8s.ConfigMapGetter
+	updater   func(ctx context.Context, namespace string) k8s.ConfigMapUpdater
+	recorder  record.EventRecorder
+}
+
+// NewConfigMapInstallStatus returns a new instance of InstallStatusRecorder
+// that records the state of install against the given install config
+// ConfigMap
+func NewConfigMapInstallStatus(namespace, configName string) InstallStatusRecorder {
+	return newConfigMapInstallStatus(namespace, configName)
+}
+
+// newConfigMapInstallStatus returns a new instance of configMapInstallStatus
+//
+// NOTE:
+//  Events are recorded via the event recorder shared by this process
+func newConfigMapInstallStatus(namespace, configName string) *configMapInstallStatus {
+	return &configMapInstallStatus{
+		name:      configName,
+		namespace: namespace,
+		getter:    k8s.NewConfigMapGetterWithContext,
+		updater:   k8s.NewConfigMapUpdaterWithContext,
+		recorder:  installEventRecorder,
+	}
+}
+
+// Progressing sets the progressing condition & records the corresponding
+// event
+func (s *configMapInstallStatus) Progressing(ctx context.Context) error {
+	cm, err := s.annotate(ctx, nil, newInstallCondition(InstallConditionProgressing, corev1.ConditionTrue, "Installing", "install is in progress"))
+	if err != nil {
+		return err
+	}
+
+	s.recordEvents(cm, installEvent{corev1.EventTypeNormal, InstallEventReasonInstalling, "Install is in progress"})
+	return nil
+}
+
+// Record sets the status annotations as well as conditions that describe the
+// given install run & records the corresponding events
+func (s *configMapInstallStatus) Record(ctx context.Context, config *InstallConfig, report InstallReport) error {
+	cm, err := s.annotate(ctx, installStatusAnnotations(report), installConditionsFor(report)...)
+	if err != nil {
+		return err
+	}
+
+	var versions []string
+	if config != nil {
+		for _, install := range config.Spec.Install {
+			versions = append(versions, install.Version)
+		}
+	}
+
+	s.recordEvents(cm, installEventsFor(versions, report)...)
+	return nil
+}
+
+// annotate sets the given annotations & conditions against the install
+// config
+//
+// NOTE:
+//  An annotation with empty value is removed. Conflicts are retried. The
+// install config is updated within the given context.
+func (s *configMapInstallStatus) annotate(ctx context.Context, annotations map[string]string, conditions ...InstallCondition) (cm *corev1.ConfigMap, err error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		cm, err = s.getter(ctx, s.namespace).Get(s.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to update status of install config '%s'", s.name)
+		}
+
+		if cm.Annotations == nil {
+			cm.Annotations = map[string]string{}
+		}
+		for key, value := range annotations {
+			if len(value) == 0 {
+				delete(cm.Annotations, key)
+				continue
+			}
+			cm.Annotations[key] = value
+		}
+
+		if len(conditions) != 0 {
+			updated := installConditionsFrom(cm)
+			for _, condition := range conditions {
+				updated = updated.Set(condition)
+			}
+
+			raw, err := json.Marshal(updated)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to update status of install config '%s'", s.name)
+			}
+			cm.Annotations[string(InstallConditionsAnnotationKey)] = string(raw)
+		}
+
+		cm, err = s.updater(ctx, s.namespace).Update(cm)
+		if apierrors.IsConflict(err) {
+			continue
+		}
+		return cm, errors.Wrapf(err, "failed to update status of install config '%s'", s.name)
+	}
+
+	return nil, fmt.Errorf("too many conflicts: failed to update status of install config '%s'", s.name)
+}
+
+// recordEvents records the given events against the given install config
+func (s *configMapInstallStatus) recordEvents(cm *corev1.ConfigMap, events ...installEvent) {
+	for _, e := range events {
+		s.recorder.Event(cm, e.eventType, string(e.reason), e.message)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeInstallStatus returns an install status that records against an
+// in-memory install config & a fake event recorder
+func fakeInstallStatus(recorder record.EventRecorder) *configMapInstallStatus {
+	stored := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "install-config", Namespace: "openebs"}}
+	getter := k8s.ConfigMapGetterFunc(func(name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
+		return stored.DeepCopy(), nil
+	})
+	updater := k8s.ConfigMapUpdaterFunc(func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		stored = cm.DeepCopy()
+		return cm, nil
+	})
+	return &configMapInstallStatus{
+		name:     "install-config",
+		getter:   func(ctx context.Context, namespace string) k8s.ConfigMapGetter { return getter },
+		updater:  func(ctx context.Context, namespace string) k8s.ConfigMapUpdater { return updater },
+		recorder: recorder,
+	}
+}
+
+func TestInstallEventsFor(t *testing.T) {
+	tests := map[string]struct {
+		items         []InstallReportItem
+		expectReason  InstallEventReason
+		expectMessage string
+		expectEvents  int
+	}{
+		"successful install": {
+			items:         []InstallReportItem{{Action: InstallActionCreated}, {Action: InstallActionUnchanged}},
+			expectReason:  InstallEventReasonInstalled,
+			expectMessage: "2 objects applied",
+			expectEvents:  1,
+		},
+		"failed install": {
+			items:         []InstallReportItem{{Action: InstallActionCreated}, {Kind: "ConfigMap", Name: "one", Action: InstallActionFailed, Error: "boom"}},
+			expectReason:  InstallEventReasonInstallFailed,
+			expectMessage: "1 objects applied, 1 failed, 0 not attempted",
+			expectEvents:  2,
+		},
+		"interrupted install": {
+			items:         []InstallReportItem{{Action: InstallActionCreated}, {Action: InstallActionNotAttempted}, {Action: InstallActionNotAttempted}},
+			expectReason:  InstallEventReasonInstallFailed,
+			expectMessage: "1 objects applied, 0 failed, 2 not attempted",
+			expectEvents:  1,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			events := installEventsFor([]string{"0.7.0"}, newInstallReport(mock.items, nil))
+			if len(events) != mock.expectEvents {
+				t.Fatalf("expected '%d' events: got '%v'", mock.expectEvents, events)
+			}
+			if events[0].reason != mock.expectReason {
+				t.Fatalf("expected reason '%s': got '%s'", mock.expectReason, events[0].reason)
+			}
+			if !strings.Contains(events[0].message, mock.expectMessage) {
+				t.Fatalf("expected message to contain '%s': got '%s'", mock.expectMessage, events[0].message)
+			}
+		})
+	}
+}
+
+func TestInstallEventsForTooManyFailures(t *testing.T) {
+	var items []InstallReportItem
+	for idx := 0; idx < maxFailureEvents+5; idx++ {
+		items = append(items, InstallReportItem{Kind: "ConfigMap", Name: "one", Action: InstallActionFailed, Error: "boom"})
+	}
+
+	events := installEventsFor([]string{"0.7.0"}, newInstallReport(items, nil))
+	if len(events) != maxFailureEvents+1 {
+		t.Fatalf("expected '%d' events: got '%d'", maxFailureEvents+1, len(events))
+	}
+}
+
+func TestInstallStatusRecord(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	status := fakeInstallStatus(recorder)
+	config := &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}
+
+	if err := status.Progressing(context.Background()); err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	report := newInstallReport([]InstallReportItem{{Kind: "ConfigMap", Name: "one", Action: InstallActionFailed, Error: "boom"}}, nil)
+	if err := status.Record(context.Background(), config, report); err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+
+	cm, _ := status.getter(context.Background(), "openebs").Get("install-config", metav1.GetOptions{})
+	if cm.Annotations[string(InstallStatusAnnotationKey)] != string(InstallOutcomeFailed) {
+		t.Fatalf("expected status annotation '%s': got '%v'", InstallOutcomeFailed, cm.Annotations)
+	}
+	conditions := installConditionsFrom(cm)
+	if degraded, found := conditions.Get(InstallConditionDegraded); !found || degraded.Status != corev1.ConditionTrue {
+		t.Fatalf("expected degraded condition to be true: got '%v'", conditions)
+	}
+
+	expected := []string{
+		"Normal Installing Install is in progress",
+		"Warning InstallFailed Failed to install version 0.7.0",
+		"Warning ArtifactFailed Failed to apply ConfigMap 'one': boom",
+	}
+	for _, prefix := range expected {
+		select {
+		case got := <-recorder.Events:
+			if !strings.HasPrefix(got, prefix) {
+				t.Fatalf("expected event '%s': got '%s'", prefix, got)
+			}
+		default:
+			t.Fatalf("expected event '%s': got none", prefix)
+		}
+	}
+}
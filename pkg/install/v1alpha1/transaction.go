@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"strconv"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// isTransactionalInstall returns true if install should be rolled back on
+// failure
+func isTransactionalInstall() bool {
+	transactional, _ := strconv.ParseBool(env.Get(string(EnvKeyForInstallTransactional)))
+	return transactional
+}
+
+// RollbackAction is a typed string to represent the action taken against a
+// resource while rolling back an install
+type RollbackAction string
+
+const (
+	// RollbackActionDeleted indicates the resource created by the install was
+	// deleted
+	RollbackActionDeleted RollbackAction = "deleted"
+	// RollbackActionRestored indicates the resource updated by the install
+	// was restored to its prior state
+	RollbackActionRestored RollbackAction = "restored"
+)
+
+// RollbackReportItem is the outcome of rolling back a single resource
+type RollbackReportItem struct {
+	Kind      string         `json:"kind"`
+	Namespace string         `json:"namespace,omitempty"`
+	Name      string         `json:"name"`
+	Action    RollbackAction `json:"action"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// transactionEntry is a resource that was mutated during a transactional
+// install
+type transactionEntry struct {
+	// desired is the resource that was applied
+	desired *unstructured.Unstructured
+	// snapshot is the state of the resource prior to the install; nil if the
+	// resource did not exist
+	snapshot *unstructured.Unstructured
+	// action that was taken against the resource
+	action InstallAction
+}
+
+// snapshotUnstructured returns the live state of the given unstructured
+// instance; nil if it is not available in kubernetes cluster
+func snapshotUnstructured(ctx context.Context, unstruct *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	get := k8s.NewResourceGetterWithContext(ctx, GroupVersionResourceFromGVK(unstruct), unstruct.GetNamespace())
+	snapshot, err := get(unstruct.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to snapshot resource '%s'", unstruct.GetName())
+	}
+
+	return snapshot, nil
+}
+
+// rollbackTransaction reverts the given transaction entries in the reverse
+// order of their application
+//
+// NOTE:
+//  Rollback is not bound to the context of install so that an interrupted
+// install is rolled back as well
+func rollbackTransaction(journal []transactionEntry) (items []RollbackReportItem) {
+	for idx := len(journal) - 1; idx >= 0; idx-- {
+		entry := journal[idx]
+		if entry.action != InstallActionCreated && entry.action != InstallActionUpdated {
+			continue
+		}
+		items = append(items, rollbackEntry(entry))
+	}
+
+	return
+}
+
+// rollbackEntry reverts a single transaction entry by deleting the resource
+// that was created or by restoring the resource that was updated
+func rollbackEntry(entry transactionEntry) RollbackReportItem {
+	unstruct := entry.desired
+	gvr := GroupVersionResourceFromGVK(unstruct)
+	namespace := unstruct.GetNamespace()
+
+	item := RollbackReportItem{
+		Kind:      unstruct.GetKind(),
+		Namespace: namespace,
+		Name:      unstruct.GetName(),
+	}
+
+	var err error
+	if entry.action == InstallActionCreated || entry.snapshot == nil {
+		item.Action = RollbackActionDeleted
+		err = k8s.NewResourceDeleter(gvr, namespace)(unstruct.GetName(), &metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+	} else {
+		item.Action = RollbackActionRestored
+		err = restoreSnapshot(entry.snapshot)
+	}
+
+	if err != nil {
+		item.Error = errors.Wrapf(err, "failed to rollback resource '%s'", unstruct.GetName()).Error()
+	}
+
+	return item
+}
+
+// restoreSnapshot updates the live resource with its snapshot
+func restoreSnapshot(snapshot *unstructured.Unstructured) error {
+	gvr := GroupVersionResourceFromGVK(snapshot)
+	namespace := snapshot.GetNamespace()
+
+	restored := snapshot.DeepCopy()
+
+	live, err := k8s.NewResourceGetter(gvr, namespace)(snapshot.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		restored.SetResourceVersion("")
+		_, err = k8s.NewResourceCreator(gvr, namespace)(restored)
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	restored.SetResourceVersion(live.GetResourceVersion())
+
+	_, err = k8s.NewResourceUpdater(gvr, namespace)(restored)
+	return err
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIsTransactionalInstall(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected bool
+	}{
+		"not set":  {value: "", expected: false},
+		"true":     {value: "true", expected: true},
+		"false":    {value: "false", expected: false},
+		"invalid":  {value: "yes please", expected: false},
+		"numeric":  {value: "1", expected: true},
+		"in upper": {value: "TRUE", expected: true},
+	}
+
+	defer os.Unsetenv(string(EnvKeyForInstallTransactional))
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(string(EnvKeyForInstallTransactional), mock.value)
+			if got := isTransactionalInstall(); got != mock.expected {
+				t.Fatalf("expected transactional '%t': got '%t'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestRollbackTransaction(t *testing.T) {
+	tests := map[string]struct {
+		journal       []transactionEntry
+		expectNames   []string
+		expectActions []RollbackAction
+	}{
+		"empty journal": {},
+		"resources that were not mutated": {
+			journal: []transactionEntry{
+				{desired: fakeUnstructured("ConfigMap", "unchanged"), action: InstallActionUnchanged},
+				{desired: fakeUnstructured("ConfigMap", "failed"), action: InstallActionFailed},
+				{desired: fakeUnstructured("ConfigMap", "not-attempted"), action: InstallActionNotAttempted},
+			},
+		},
+		"mutated resources in reverse order": {
+			journal: []transactionEntry{
+				{desired: fakeUnstructured("ConfigMap", "created"), action: InstallActionCreated},
+				{desired: fakeUnstructured("ConfigMap", "unchanged"), action: InstallActionUnchanged},
+				// an updated resource without a snapshot can only be deleted
+				{desired: fakeUnstructured("ConfigMap", "updated"), action: InstallActionUpdated},
+			},
+			expectNames:   []string{"updated", "created"},
+			expectActions: []RollbackAction{RollbackActionDeleted, RollbackActionDeleted},
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			items := rollbackTransaction(mock.journal)
+			if len(items) != len(mock.expectNames) {
+				t.Fatalf("expected '%d' rolled back resources: got '%+v'", len(mock.expectNames), items)
+			}
+			for idx, item := range items {
+				if item.Name != mock.expectNames[idx] || item.Action != mock.expectActions[idx] {
+					t.Fatalf("expected '%s' to be '%s': got '%+v'", mock.expectNames[idx], mock.expectActions[idx], item)
+				}
+				if item.Kind != "ConfigMap" || item.Namespace != "openebs" {
+					t.Fatalf("expected rolled back resource 'ConfigMap' in 'openebs': got '%+v'", item)
+				}
+			}
+		})
+	}
+}
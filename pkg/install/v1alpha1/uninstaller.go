@@ -0,0 +1,165 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Uninstaller abstracts un-installation
+type Uninstaller interface {
+	Uninstall(ctx context.Context) (errors []error)
+}
+
+// simpleUninstaller un-installs artifacts by making use of install config
+//
+// NOTE:
+//  This is an implementation of Uninstaller
+type simpleUninstaller struct {
+	configGetter   ContextConfigGetterFunc
+	artifactLister VersionArtifactLister
+	transformer    ArtifactToUnstructuredListTransformer
+	installErrors
+}
+
+// Uninstall deletes the resources specified in the install config's
+// uninstall section till the given context is done
+//
+// NOTE:
+//  This is an implementation of Uninstaller interface
+func (u *simpleUninstaller) Uninstall(ctx context.Context) []error {
+	if u.configGetter == nil {
+		return u.addError(fmt.Errorf("nil config getter: simple uninstaller failed"))
+	}
+
+	config, err := u.configGetter(ctx, env.Get(string(EnvKeyForInstallConfigName)))
+	if err != nil {
+		return u.addError(errors.Wrap(err, "simple uninstaller failed"))
+	}
+
+	for _, uninstall := range config.Spec.Uninstall {
+		list, err := u.artifactLister(uninstall.Version)
+		if err != nil {
+			u.addError(errors.Wrapf(err, "simple uninstaller failed to list artifacts for version '%s'", uninstall.Version))
+			continue
+		}
+
+		selector, err := labels.Parse(uninstall.FilterOptions.LabelSelector)
+		if err != nil {
+			u.addError(errors.Wrapf(err, "simple uninstaller failed to parse label selector for version '%s'", uninstall.Version))
+			continue
+		}
+
+		// transform list of artifacts to list of unstructured instances
+		unstructs, errs := u.transformer(list)
+		if len(errs) != 0 {
+			u.addErrors(errs)
+		}
+
+		for _, unstruct := range unstructs {
+			err := deleteUnstructured(ctx, unstruct, uninstall.FilterOptions.Namespace, selector)
+			if err != nil {
+				u.addError(err)
+			}
+		}
+	}
+
+	return u.errors
+}
+
+// uninstallNamespace returns the namespace the given unstructured instance
+// is looked up in during uninstall
+//
+// NOTE:
+//  An artifact without a namespace is cluster scoped e.g. CASTemplate & is
+// never looked up in the filter namespace. A namespaced artifact is looked up
+// in the filter namespace if one is specified.
+func uninstallNamespace(unstruct *unstructured.Unstructured, filterNamespace string) string {
+	if len(unstruct.GetNamespace()) == 0 || len(strings.TrimSpace(filterNamespace)) == 0 {
+		return unstruct.GetNamespace()
+	}
+	return strings.TrimSpace(filterNamespace)
+}
+
+// deleteUnstructured deletes the given unstructured instance from kubernetes
+// cluster if the live instance matches the provided filters
+//
+// NOTE:
+//  A resource that is not found in the cluster or does not match the label
+// selector is skipped. The resource is looked up as well as deleted within
+// the given context.
+func deleteUnstructured(ctx context.Context, unstruct *unstructured.Unstructured, filterNamespace string, selector labels.Selector) error {
+	if unstruct == nil {
+		return fmt.Errorf("nil resource instance: failed to uninstall resource")
+	}
+
+	namespace := uninstallNamespace(unstruct, filterNamespace)
+	gvr := GroupVersionResourceFromGVK(unstruct)
+	name := unstruct.GetName()
+
+	live, err := k8s.NewResourceGetterWithContext(ctx, gvr, namespace)(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to uninstall resource '%s/%s' of '%s'", namespace, name, gvr)
+	}
+
+	if !selector.Matches(labels.Set(live.GetLabels())) {
+		return nil
+	}
+
+	err = k8s.NewResourceDeleterWithContext(ctx, gvr, namespace)(name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to uninstall resource '%s/%s' of '%s'", namespace, name, gvr)
+	}
+
+	return nil
+}
+
+// SimpleUninstaller returns a new instance of simpleUninstaller
+func SimpleUninstaller() *simpleUninstaller {
+	return NewContextSimpleUninstaller(WithContextConfigMapConfigGetter(env.Get(string(EnvKeyForInstallConfigNamespace))))
+}
+
+// NewSimpleUninstaller returns a new instance of simpleUninstaller that makes
+// use of the provided config getter to fetch the install config
+func NewSimpleUninstaller(configGetter ConfigGetterFunc) *simpleUninstaller {
+	return NewContextSimpleUninstaller(IgnoreContextConfigGetter(configGetter))
+}
+
+// NewContextSimpleUninstaller returns a new instance of simpleUninstaller that
+// makes use of the provided config getter to fetch the install config within
+// the context of the uninstall run
+func NewContextSimpleUninstaller(configGetter ContextConfigGetterFunc) *simpleUninstaller {
+	return &simpleUninstaller{
+		configGetter:   configGetter,
+		artifactLister: ListArtifactsByVersion,
+		transformer:    TransformArtifactToUnstructuredList,
+	}
+}
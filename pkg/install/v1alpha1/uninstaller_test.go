@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUninstallNamespace(t *testing.T) {
+	tests := map[string]struct {
+		kind            string
+		namespace       string
+		filterNamespace string
+		expected        string
+	}{
+		"cluster scoped without filter": {kind: "CASTemplate", expected: ""},
+		"cluster scoped with filter":    {kind: "CASTemplate", filterNamespace: "openebs", expected: ""},
+		"namespaced without filter":     {kind: "ConfigMap", namespace: "openebs", expected: "openebs"},
+		"namespaced with filter":        {kind: "ConfigMap", namespace: "openebs", filterNamespace: "storage", expected: "storage"},
+		"namespaced with blank filter":  {kind: "ConfigMap", namespace: "openebs", filterNamespace: "  ", expected: "openebs"},
+		"namespaced with padded filter": {kind: "ConfigMap", namespace: "openebs", filterNamespace: " storage ", expected: "storage"},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			unstruct := &unstructured.Unstructured{}
+			unstruct.SetKind(mock.kind)
+			unstruct.SetName("test")
+			unstruct.SetNamespace(mock.namespace)
+
+			if got := uninstallNamespace(unstruct, mock.filterNamespace); got != mock.expected {
+				t.Fatalf("expected namespace '%s': got '%s'", mock.expected, got)
+			}
+		})
+	}
+}
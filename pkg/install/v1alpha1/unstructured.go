@@ -17,7 +17,9 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
 	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -35,8 +37,8 @@ func GroupVersionResourceFromGVK(unstructured *unstructured.Unstructured) (gvr s
 
 	gvr.Group = gvk.Group
 	gvr.Version = gvk.Version
-	// Resource is assumed as plural of Kind
-	gvr.Resource = gvk.Kind + "s"
+	// Resource is assumed as lower cased plural of Kind
+	gvr.Resource = strings.ToLower(gvk.Kind) + "s"
 
 	return
 }
@@ -52,7 +54,15 @@ type ArtifactToUnstructuredListTransformer func(list ArtifactList) ([]*unstructu
 //  This is an implementation of ArtifactToUnstructuredListTransformer
 func TransformArtifactToUnstructuredList(list ArtifactList) (unstructuredList []*unstructured.Unstructured, errs []error) {
 	for _, artifact := range list.Items {
-		unstructured, err := k8s.BuildUnstructured([]byte(artifact.Doc))
+		// artifacts are YAML documents whereas unstructured instances are
+		// built from JSON
+		raw, err := yaml.YAMLToJSON([]byte(artifact.Doc))
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to transform artifact to an unstructured instance"))
+			continue
+		}
+
+		unstructured, err := k8s.BuildUnstructured(raw)
 		if err != nil {
 			errs = append(errs, errors.Wrap(err, "failed to transform artifact to an unstructured instance"))
 			continue
@@ -87,7 +97,58 @@ func updateUnstructuredNamespace(install Install) k8s.UnstructuredMiddleware {
 	}
 }
 
-// updateUnstructuredLabels updates the unstructured's labels
+// validateMergeStrategy returns error if the given merge strategy is not
+// understood by installer
+//
+// NOTE:
+//  An empty merge strategy is valid & implies merge
+func validateMergeStrategy(strategy MergeStrategy) error {
+	switch strategy {
+	case "", MergeStrategyMerge, MergeStrategyReplace, MergeStrategyMergeWithoutOverride:
+		return nil
+	default:
+		return fmt.Errorf("invalid merge strategy '%s': supported strategies are '%s', '%s' and '%s'",
+			strategy, MergeStrategyMerge, MergeStrategyReplace, MergeStrategyMergeWithoutOverride)
+	}
+}
+
+// mergeStringMaps returns a new map having the entries of the given
+// existing map & the given desired map as per the given strategy; the given
+// keys are removed from the result
+func mergeStringMaps(existing, desired map[string]string, strategy MergeStrategy, remove []string) map[string]string {
+	merged := map[string]string{}
+	if strategy != MergeStrategyReplace {
+		for key, value := range existing {
+			merged[key] = value
+		}
+	}
+
+	for key, value := range desired {
+		if _, found := merged[key]; found && strategy == MergeStrategyMergeWithoutOverride {
+			continue
+		}
+		merged[key] = value
+	}
+
+	for _, key := range remove {
+		delete(merged, key)
+	}
+	return merged
+}
+
+// isMergeNoop returns true if setting the given desired map with the given
+// strategy & removals leaves the existing map unchanged
+func isMergeNoop(desired map[string]string, strategy MergeStrategy, remove []string) bool {
+	return len(desired) == 0 && len(remove) == 0 && strategy != MergeStrategyReplace
+}
+
+// updateUnstructuredLabels sets the install labels against the unstructured's
+// labels as per the install merge strategy & removes the install's label keys
+// to be removed
+//
+// NOTE:
+//  Existing labels are retained unless overridden by the install labels if
+// the merge strategy is not specified
 //
 // NOTE:
 //  This is an implementation of WithInstallUnstructuredUpdater
@@ -97,16 +158,23 @@ func updateUnstructuredLabels(install Install) k8s.UnstructuredMiddleware {
 			return unstructured
 		}
 
-		if install.SetOptions.Labels == nil || len(install.SetOptions.Labels) == 0 {
+		options := install.SetOptions
+		if isMergeNoop(options.Labels, options.MergeStrategy, options.RemoveLabels) {
 			return unstructured
 		}
 
-		unstructured.SetLabels(install.SetOptions.Labels)
+		unstructured.SetLabels(mergeStringMaps(unstructured.GetLabels(), options.Labels, options.MergeStrategy, options.RemoveLabels))
 		return unstructured
 	}
 }
 
-// updateUnstructuredAnnotations updates the unstructured's annotations
+// updateUnstructuredAnnotations sets the install annotations against the
+// unstructured's annotations as per the install merge strategy & removes the
+// install's annotation keys to be removed
+//
+// NOTE:
+//  Existing annotations are retained unless overridden by the install
+// annotations if the merge strategy is not specified
 //
 // NOTE:
 //  This is an implementation of WithInstallUnstructuredUpdater
@@ -116,11 +184,12 @@ func updateUnstructuredAnnotations(install Install) k8s.UnstructuredMiddleware {
 			return unstructured
 		}
 
-		if install.SetOptions.Annotations == nil || len(install.SetOptions.Annotations) == 0 {
+		options := install.SetOptions
+		if isMergeNoop(options.Annotations, options.MergeStrategy, options.RemoveAnnotations) {
 			return unstructured
 		}
 
-		unstructured.SetAnnotations(install.SetOptions.Annotations)
+		unstructured.SetAnnotations(mergeStringMaps(unstructured.GetAnnotations(), options.Annotations, options.MergeStrategy, options.RemoveAnnotations))
 		return unstructured
 	}
 }
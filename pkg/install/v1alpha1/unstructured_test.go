@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdateUnstructuredLabels(t *testing.T) {
+	existing := map[string]string{"openebs.io/cas-type": "jiva", "team": "platform"}
+
+	tests := map[string]struct {
+		existing map[string]string
+		options  SetOptions
+		expected map[string]string
+	}{
+		"no install labels retain existing labels": {
+			existing: existing,
+			expected: existing,
+		},
+		"default strategy merges & overrides": {
+			existing: existing,
+			options:  SetOptions{Labels: map[string]string{"team": "storage", "env": "prod"}},
+			expected: map[string]string{"openebs.io/cas-type": "jiva", "team": "storage", "env": "prod"},
+		},
+		"merge strategy on artifact without labels": {
+			options:  SetOptions{Labels: map[string]string{"env": "prod"}, MergeStrategy: MergeStrategyMerge},
+			expected: map[string]string{"env": "prod"},
+		},
+		"replace strategy discards existing labels": {
+			existing: existing,
+			options:  SetOptions{Labels: map[string]string{"env": "prod"}, MergeStrategy: MergeStrategyReplace},
+			expected: map[string]string{"env": "prod"},
+		},
+		"replace strategy without install labels clears labels": {
+			existing: existing,
+			options:  SetOptions{MergeStrategy: MergeStrategyReplace},
+			expected: nil,
+		},
+		"merge without override retains existing values": {
+			existing: existing,
+			options:  SetOptions{Labels: map[string]string{"team": "storage", "env": "prod"}, MergeStrategy: MergeStrategyMergeWithoutOverride},
+			expected: map[string]string{"openebs.io/cas-type": "jiva", "team": "platform", "env": "prod"},
+		},
+		"remove existing label": {
+			existing: existing,
+			options:  SetOptions{RemoveLabels: []string{"team"}},
+			expected: map[string]string{"openebs.io/cas-type": "jiva"},
+		},
+		"remove takes precedence over install labels": {
+			existing: existing,
+			options:  SetOptions{Labels: map[string]string{"env": "prod"}, RemoveLabels: []string{"env", "missing"}},
+			expected: existing,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			unstruct := fakeUnstructured("CASTemplate", "jiva-volume-create-default-0.7.0")
+			unstruct.SetLabels(mock.existing)
+
+			got := updateUnstructuredLabels(Install{SetOptions: mock.options})(unstruct).GetLabels()
+			if len(got) != len(mock.expected) || (len(got) != 0 && !reflect.DeepEqual(got, mock.expected)) {
+				t.Fatalf("expected labels '%v': got '%v'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateUnstructuredAnnotations(t *testing.T) {
+	existing := map[string]string{"openebs.io/volume-monitor": "true"}
+
+	tests := map[string]struct {
+		options  SetOptions
+		expected map[string]string
+	}{
+		"default strategy merges": {
+			options:  SetOptions{Annotations: map[string]string{"owner": "storage"}},
+			expected: map[string]string{"openebs.io/volume-monitor": "true", "owner": "storage"},
+		},
+		"replace strategy discards existing annotations": {
+			options:  SetOptions{Annotations: map[string]string{"owner": "storage"}, MergeStrategy: MergeStrategyReplace},
+			expected: map[string]string{"owner": "storage"},
+		},
+		"merge without override retains existing values": {
+			options:  SetOptions{Annotations: map[string]string{"openebs.io/volume-monitor": "false"}, MergeStrategy: MergeStrategyMergeWithoutOverride},
+			expected: existing,
+		},
+		"remove annotations do not touch labels": {
+			options:  SetOptions{RemoveAnnotations: []string{"openebs.io/volume-monitor"}, RemoveLabels: []string{"owner"}},
+			expected: nil,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			unstruct := fakeUnstructured("CASTemplate", "jiva-volume-create-default-0.7.0")
+			unstruct.SetAnnotations(existing)
+
+			got := updateUnstructuredAnnotations(Install{SetOptions: mock.options})(unstruct).GetAnnotations()
+			if len(got) != len(mock.expected) || (len(got) != 0 && !reflect.DeepEqual(got, mock.expected)) {
+				t.Fatalf("expected annotations '%v': got '%v'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateMergeStrategy(t *testing.T) {
+	tests := map[string]struct {
+		strategy  MergeStrategy
+		expectErr bool
+	}{
+		"empty":                  {},
+		"merge":                  {strategy: MergeStrategyMerge},
+		"replace":                {strategy: MergeStrategyReplace},
+		"merge without override": {strategy: MergeStrategyMergeWithoutOverride},
+		"unsupported":            {strategy: "overwrite", expectErr: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := validateMergeStrategy(mock.strategy); mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,519 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	k8s "github.com/AmitKumarDas/decide/pkg/client/k8s/v1alpha1"
+	env "github.com/AmitKumarDas/decide/pkg/env/v1alpha1"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ArtifactChange is a typed string to represent how an artifact changes
+// between two versions
+type ArtifactChange string
+
+const (
+	// ArtifactAdded indicates the artifact is available only in the new
+	// version
+	ArtifactAdded ArtifactChange = "added"
+	// ArtifactChanged indicates the artifact is available in both the
+	// versions but differs
+	ArtifactChanged ArtifactChange = "changed"
+	// ArtifactRemoved indicates the artifact is available only in the old
+	// version
+	ArtifactRemoved ArtifactChange = "removed"
+	// ArtifactUnchanged indicates the artifact is same in both the versions
+	ArtifactUnchanged ArtifactChange = "unchanged"
+)
+
+// UpgradePlanItem is the change of a single artifact between two versions
+type UpgradePlanItem struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Namespace  string          `json:"namespace,omitempty"`
+	Name       string          `json:"name"`
+	Change     ArtifactChange  `json:"change"`
+	Diffs      []k8s.FieldDiff `json:"diffs,omitempty"`
+}
+
+// UpgradePlan is the list of artifact changes between two versions
+type UpgradePlan struct {
+	From  string            `json:"from"`
+	To    string            `json:"to"`
+	Items []UpgradePlanItem `json:"items"`
+}
+
+// Count returns the number of plan items with the given change
+func (p UpgradePlan) Count(change ArtifactChange) (count int) {
+	for _, item := range p.Items {
+		if item.Change == change {
+			count++
+		}
+	}
+	return
+}
+
+// Names returns the names of the artifacts of the given kind having the
+// given change
+func (p UpgradePlan) Names(kind string, change ArtifactChange) map[string]bool {
+	names := map[string]bool{}
+	for _, item := range p.Items {
+		if item.Kind == kind && item.Change == change {
+			names[item.Name] = true
+		}
+	}
+	return names
+}
+
+// newUpgradePlanItem returns a new upgrade plan item for the given
+// unstructured instance
+func newUpgradePlanItem(unstruct *unstructured.Unstructured, change ArtifactChange) UpgradePlanItem {
+	return UpgradePlanItem{
+		APIVersion: unstruct.GetAPIVersion(),
+		Kind:       unstruct.GetKind(),
+		Namespace:  unstruct.GetNamespace(),
+		Name:       unstruct.GetName(),
+		Change:     change,
+	}
+}
+
+// NewUpgradePlan compares the unstructured instances of the old version with
+// that of the new version
+//
+// NOTE:
+//  Artifacts are matched by their kind, namespace & name. The install version
+// label as well as the digest annotation are not considered as a change
+// since the digest covers the install version label.
+func NewUpgradePlan(from, to string, fromList, toList []*unstructured.Unstructured) (plan UpgradePlan) {
+	plan.From = from
+	plan.To = to
+
+	old := map[string]*unstructured.Unstructured{}
+	for _, unstruct := range fromList {
+		old[unstructuredKey(unstruct)] = unstruct
+	}
+
+	desired := map[string]bool{}
+	for _, unstruct := range toList {
+		key := unstructuredKey(unstruct)
+		desired[key] = true
+
+		prior, found := old[key]
+		if !found {
+			plan.Items = append(plan.Items, newUpgradePlanItem(unstruct, ArtifactAdded))
+			continue
+		}
+
+		item := newUpgradePlanItem(unstruct, ArtifactUnchanged)
+		item.Diffs = withoutInstallMetadataDiffs(k8s.DiffUnstructuredAll(prior, unstruct))
+		if len(item.Diffs) != 0 {
+			item.Change = ArtifactChanged
+		}
+		plan.Items = append(plan.Items, item)
+	}
+
+	for _, unstruct := range fromList {
+		if !desired[unstructuredKey(unstruct)] {
+			plan.Items = append(plan.Items, newUpgradePlanItem(unstruct, ArtifactRemoved))
+		}
+	}
+
+	return
+}
+
+// withoutInstallMetadataDiffs returns the given diffs without the diffs of
+// the install version label & the digest annotation
+func withoutInstallMetadataDiffs(diffs []k8s.FieldDiff) (filtered []k8s.FieldDiff) {
+	ignored := map[string]bool{
+		"metadata.labels." + string(InstallVersionLabelKey):             true,
+		"metadata.annotations." + string(ProvenanceAnnotationKeyDigest): true,
+	}
+	for _, diff := range diffs {
+		if !ignored[diff.Path] {
+			filtered = append(filtered, diff)
+		}
+	}
+	return
+}
+
+// MigrationHook abstracts migrating the resources that are not installed by
+// installer but depend on the installed artifacts e.g. StorageClasses that
+// refer to CASTemplates
+//
+// NOTE:
+//  A migration hook is executed after the artifacts of the new version are
+// applied & before the artifacts of the old version are pruned. It is
+// expected to stop once the given context is done.
+type MigrationHook func(ctx context.Context, plan UpgradePlan) error
+
+// AnyVersion is used to register a migration hook that is executed for every
+// upgrade
+const AnyVersion = "*"
+
+// namedMigrationHook is a migration hook along with its name
+type namedMigrationHook struct {
+	name    string
+	migrate MigrationHook
+}
+
+// migrationHookRegistry has the migration hooks mapped to the version
+// upgrades they are registered for
+type migrationHookRegistry struct {
+	sync.RWMutex
+	hooks map[string][]namedMigrationHook
+}
+
+// migrationHooks is the registry of migration hooks
+var migrationHooks = &migrationHookRegistry{
+	hooks: map[string][]namedMigrationHook{
+		migrationKey(AnyVersion, AnyVersion): {
+			{name: "storageclass-cas-templates", migrate: MigrateStorageClassTemplates},
+		},
+	},
+}
+
+// migrationKey returns the key of a version upgrade
+func migrationKey(from, to string) string {
+	return from + "->" + to
+}
+
+// RegisterMigrationHook registers the given migration hook against the
+// upgrade from the given old version to the given new version
+//
+// NOTE:
+//  Either of the versions can be AnyVersion
+func RegisterMigrationHook(from, to, name string, hook MigrationHook) {
+	migrationHooks.Lock()
+	defer migrationHooks.Unlock()
+
+	key := migrationKey(from, to)
+	migrationHooks.hooks[key] = append(migrationHooks.hooks[key], namedMigrationHook{name: name, migrate: hook})
+}
+
+// migrationHooksFor returns the migration hooks in the order they need to be
+// executed for the given upgrade
+func migrationHooksFor(from, to string) (hooks []namedMigrationHook) {
+	migrationHooks.RLock()
+	defer migrationHooks.RUnlock()
+
+	for _, key := range []string{
+		migrationKey(AnyVersion, AnyVersion),
+		migrationKey(from, AnyVersion),
+		migrationKey(AnyVersion, to),
+		migrationKey(from, to),
+	} {
+		hooks = append(hooks, migrationHooks.hooks[key]...)
+	}
+	return
+}
+
+// isCASTemplateAnnotation returns true if the given annotation refers to a
+// CASTemplate e.g. cas.openebs.io/create-template
+func isCASTemplateAnnotation(key string) bool {
+	return strings.HasPrefix(key, "cas.openebs.io/") && strings.HasSuffix(key, "-template")
+}
+
+// MigrateStorageClassTemplates rewrites the CASTemplate annotations of the
+// StorageClasses that refer to the CASTemplates removed by the upgrade. The
+// new name is derived by replacing the old version suffix with the new
+// version e.g. cstor-volume-create-default-0.7.0 becomes
+// cstor-volume-create-default-0.8.0
+//
+// NOTE:
+//  An annotation is rewritten only if the new version has the derived
+// CASTemplate
+//
+// NOTE:
+//  This is an implementation of MigrationHook
+func MigrateStorageClassTemplates(ctx context.Context, plan UpgradePlan) error {
+	removed := plan.Names("CASTemplate", ArtifactRemoved)
+	added := plan.Names("CASTemplate", ArtifactAdded)
+	if len(removed) == 0 || len(added) == 0 {
+		return nil
+	}
+
+	gvr := schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+	scs, err := k8s.NewResourceListerWithContext(ctx, gvr, "")(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate storage class templates")
+	}
+
+	update := k8s.NewResourceUpdaterWithContext(ctx, gvr, "")
+	for idx := range scs.Items {
+		sc := &scs.Items[idx]
+		annotations := sc.GetAnnotations()
+
+		migrated := false
+		for key, template := range annotations {
+			if !isCASTemplateAnnotation(key) || !removed[template] || !strings.HasSuffix(template, plan.From) {
+				continue
+			}
+
+			renamed := strings.TrimSuffix(template, plan.From) + plan.To
+			if added[renamed] {
+				annotations[key] = renamed
+				migrated = true
+			}
+		}
+
+		if !migrated {
+			continue
+		}
+
+		sc.SetAnnotations(annotations)
+		_, err = update(sc)
+		if err != nil {
+			return errors.Wrapf(err, "failed to migrate templates of storage class '%s'", sc.GetName())
+		}
+	}
+
+	return nil
+}
+
+// MigrationReportItem is the outcome of executing a single migration hook
+type MigrationReportItem struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// UpgradeReport is the outcome of an upgrade
+type UpgradeReport struct {
+	// Plan has the artifact changes between the versions
+	Plan UpgradePlan `json:"plan"`
+	// Install is the outcome of applying the artifacts of the new version
+	Install InstallReport `json:"install"`
+	// Migrations has the outcome of every migration hook that was executed
+	Migrations []MigrationReportItem `json:"migrations,omitempty"`
+	// Pruned has the outcome of every resource that was deleted since it is
+	// no longer part of the new version
+	Pruned []PruneReportItem `json:"pruned,omitempty"`
+	// Errors that are not specific to any resource
+	Errors []string `json:"errors,omitempty"`
+}
+
+// IsSuccess returns true if the upgrade completed without any errors
+func (r UpgradeReport) IsSuccess() bool {
+	if len(r.Errors) != 0 || !r.Install.IsSuccess() {
+		return false
+	}
+
+	for _, item := range r.Migrations {
+		if len(item.Error) != 0 {
+			return false
+		}
+	}
+
+	for _, item := range r.Pruned {
+		if item.Action == PruneActionFailed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExitCode returns the process exit code corresponding to this report
+func (r UpgradeReport) ExitCode() int {
+	if r.IsSuccess() {
+		return 0
+	}
+	return 1
+}
+
+// JSON returns the JSON representation of this report
+func (r UpgradeReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// String returns the human readable representation of this report
+func (r UpgradeReport) String() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Upgrade %s => %s: %d added, %d changed, %d removed, %d unchanged\n\n",
+		r.Plan.From, r.Plan.To, r.Plan.Count(ArtifactAdded), r.Plan.Count(ArtifactChanged),
+		r.Plan.Count(ArtifactRemoved), r.Plan.Count(ArtifactUnchanged))
+	buf.WriteString(r.Install.Table())
+
+	for _, item := range r.Migrations {
+		fmt.Fprintf(&buf, "migration: %s", item.Name)
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, ": %s", item.Error)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	for _, item := range r.Pruned {
+		fmt.Fprintf(&buf, "pruned: %s %s/%s %s", item.Action, item.Kind, item.Name, item.Namespace)
+		if len(item.Error) != 0 {
+			fmt.Fprintf(&buf, ": %s", item.Error)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	for _, err := range r.Errors {
+		fmt.Fprintf(&buf, "error: %s\n", err)
+	}
+
+	return buf.String()
+}
+
+// Upgrader abstracts upgrading the installed artifacts from one version to
+// another
+type Upgrader interface {
+	Upgrade(ctx context.Context, from, to string) (report UpgradeReport)
+}
+
+// withUpgradedConfigGetter returns a config getter that replaces the given
+// old version of the install config fetched by the given getter with the
+// given new version
+func withUpgradedConfigGetter(getter ContextConfigGetterFunc, from, to string) ContextConfigGetterFunc {
+	return func(ctx context.Context, name string) (*InstallConfig, error) {
+		config, err := getter(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		upgraded := *config
+		upgraded.Spec.Install = nil
+		for _, install := range config.Spec.Install {
+			if install.Version == from {
+				install.Version = to
+			}
+			upgraded.Spec.Install = append(upgraded.Spec.Install, install)
+		}
+
+		return &upgraded, nil
+	}
+}
+
+// installFor returns the install specs of the given version
+func installFor(config *InstallConfig, version string) (Install, bool) {
+	for _, install := range config.Spec.Install {
+		if install.Version == version {
+			return install, true
+		}
+	}
+	return Install{}, false
+}
+
+// Upgrade moves the artifacts of the install config from the given old
+// version to the given new version. The artifacts of the new version are
+// applied followed by the execution of the migration hooks. Finally the
+// artifacts of the old version that are no longer needed are pruned.
+//
+// NOTE:
+//  Migration hooks & pruning are skipped if the new version could not be
+// applied. The install config itself is not updated; it should be updated to
+// the new version to avoid a later install from reverting this upgrade.
+//
+// NOTE:
+//  Pruning is skipped if the name of the install config is not known since
+// the installed resources are not labelled with their owner
+//
+// NOTE:
+//  Upgrade stops once the given context is done. The installer is left as is
+// after the upgrade & can be re-used.
+//
+// NOTE:
+//  This is an implementation of Upgrader interface
+func (i *simpleInstaller) Upgrade(ctx context.Context, from, to string) (report UpgradeReport) {
+	i.resetErrors()
+	report.Plan.From = from
+	report.Plan.To = to
+
+	if i.configGetter == nil {
+		report.Errors = append(report.Errors, "nil config getter: simple installer failed to upgrade")
+		return
+	}
+
+	config, err := i.configGetter(ctx, env.Get(string(EnvKeyForInstallConfigName)))
+	if err != nil {
+		report.Errors = append(report.Errors, errors.Wrap(err, "simple installer failed to upgrade").Error())
+		return
+	}
+
+	install, found := installFor(config, from)
+	if !found {
+		report.Errors = append(report.Errors,
+			fmt.Sprintf("version '%s' is not specified in install config: simple installer failed to upgrade", from))
+		return
+	}
+
+	upgraded := install
+	upgraded.Version = to
+	report.Plan = NewUpgradePlan(from, to, i.installUnstructuredList(install), i.installUnstructuredList(upgraded))
+	if len(i.errors) != 0 {
+		report.Install = newInstallReport(nil, i.errors)
+		return
+	}
+
+	// apply the new version without pruning since the old version may still
+	// be in use till the migrations are done
+	configGetter, prune := i.configGetter, i.prune
+	defer func() { i.configGetter, i.prune = configGetter, prune }()
+	i.configGetter = withUpgradedConfigGetter(configGetter, from, to)
+	i.prune = false
+	report.Install = i.InstallContext(ctx)
+	if !report.Install.IsSuccess() {
+		return
+	}
+
+	for _, hook := range migrationHooksFor(from, to) {
+		item := MigrationReportItem{Name: hook.name}
+		err = hook.migrate(ctx, report.Plan)
+		if err != nil {
+			item.Error = errors.Wrapf(err, "migration hook '%s' failed", hook.name).Error()
+		}
+		report.Migrations = append(report.Migrations, item)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(pruneConfigName()) == 0 {
+		glog.Warningf("missing install config name: skipping prune of version '%s': set %s to prune", from, EnvKeyForInstallConfigName)
+		return
+	}
+
+	// nothing is pruned if the new install config could not be resolved
+	desired := i.unstructuredList(ctx)
+	if len(i.errors) != 0 {
+		for _, err := range i.errors {
+			report.Errors = append(report.Errors, err.Error())
+		}
+		return
+	}
+
+	pruned, err := i.pruneUnlisted(ctx, desired, false)
+	if err != nil {
+		report.Errors = append(report.Errors, errors.Wrap(err, "simple installer failed to upgrade").Error())
+	}
+	report.Pruned = pruned
+
+	return
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fakeVersionedUnstructured returns an unstructured instance labelled with
+// the given install version
+func fakeVersionedUnstructured(kind, name, version string, data map[string]interface{}) *unstructured.Unstructured {
+	unstruct := fakeUnstructured(kind, name)
+	unstruct.SetLabels(map[string]string{string(InstallVersionLabelKey): version})
+	if data != nil {
+		unstruct.Object["data"] = data
+	}
+	return unstruct
+}
+
+func TestNewUpgradePlan(t *testing.T) {
+	fromList := []*unstructured.Unstructured{
+		fakeVersionedUnstructured("ConfigMap", "same", "0.6.0", map[string]interface{}{"key": "value"}),
+		fakeVersionedUnstructured("ConfigMap", "edited", "0.6.0", map[string]interface{}{"key": "old"}),
+		fakeVersionedUnstructured("ConfigMap", "removed", "0.6.0", nil),
+	}
+	toList := []*unstructured.Unstructured{
+		fakeVersionedUnstructured("ConfigMap", "same", "0.7.0", map[string]interface{}{"key": "value"}),
+		fakeVersionedUnstructured("ConfigMap", "edited", "0.7.0", map[string]interface{}{"key": "new"}),
+		fakeVersionedUnstructured("ConfigMap", "added", "0.7.0", nil),
+	}
+
+	plan := NewUpgradePlan("0.6.0", "0.7.0", fromList, toList)
+	expected := map[string]ArtifactChange{
+		"same":    ArtifactUnchanged,
+		"edited":  ArtifactChanged,
+		"added":   ArtifactAdded,
+		"removed": ArtifactRemoved,
+	}
+
+	if len(plan.Items) != len(expected) {
+		t.Fatalf("expected '%d' plan items: got '%d'", len(expected), len(plan.Items))
+	}
+	for _, item := range plan.Items {
+		if item.Change != expected[item.Name] {
+			t.Fatalf("expected '%s' to be '%s': got '%s'", item.Name, expected[item.Name], item.Change)
+		}
+		if item.Change == ArtifactChanged && (len(item.Diffs) != 1 || item.Diffs[0].Path != "data.key") {
+			t.Fatalf("expected only 'data.key' to differ for '%s': got '%v'", item.Name, item.Diffs)
+		}
+	}
+	if names := plan.Names("ConfigMap", ArtifactRemoved); len(names) != 1 || !names["removed"] {
+		t.Fatalf("expected removed config map 'removed': got '%v'", names)
+	}
+}
+
+func TestNewUpgradePlanWithInstallUpdaters(t *testing.T) {
+	tests := map[string]struct {
+		toInstall     Install
+		expectChanged int
+	}{
+		"identical artifacts": {
+			toInstall: Install{Version: "0.8.0"},
+		},
+		"artifacts with a patch": {
+			toInstall: Install{
+				Version: "0.8.0",
+				SetOptions: SetOptions{Patches: []Patch{{
+					Target: PatchTarget{Kind: "CASTemplate", Name: "cstor-volume-list-default-0.7.0"},
+					Type:   PatchTypeMerge,
+					Patch:  `{"metadata":{"labels":{"patched":"true"}}}`,
+				}}},
+			},
+			expectChanged: 1,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			i := NewSimpleInstaller(nil)
+			// the new version has the same artifacts as the old version
+			i.artifactLister = func(version string) (ArtifactList, error) {
+				return RegisteredArtifactsFor070(), nil
+			}
+
+			fromList := i.installUnstructuredList(Install{Version: "0.7.0"})
+			toList := i.installUnstructuredList(mock.toInstall)
+			if len(i.errors) != 0 {
+				t.Fatalf("expected no error: got '%v'", i.errors)
+			}
+
+			plan := NewUpgradePlan("0.7.0", "0.8.0", fromList, toList)
+			if got := plan.Count(ArtifactChanged); got != mock.expectChanged {
+				t.Fatalf("expected '%d' changed artifacts: got '%d': '%+v'", mock.expectChanged, got, plan.Items)
+			}
+			if got := plan.Count(ArtifactUnchanged); got != len(toList)-mock.expectChanged {
+				t.Fatalf("expected '%d' unchanged artifacts: got '%d'", len(toList)-mock.expectChanged, got)
+			}
+		})
+	}
+}
+
+func TestWithUpgradedConfigGetter(t *testing.T) {
+	tests := map[string]struct {
+		config    *InstallConfig
+		err       error
+		expected  []string
+		expectErr bool
+	}{
+		"old version is replaced": {
+			config:   &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.6.0"}, {Version: "0.5.0"}}}},
+			expected: []string{"0.7.0", "0.5.0"},
+		},
+		"old version is not specified": {
+			config:   &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.5.0"}}}},
+			expected: []string{"0.5.0"},
+		},
+		"config getter fails": {
+			err:       fmt.Errorf("boom"),
+			expectErr: true,
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			getter := withUpgradedConfigGetter(func(ctx context.Context, name string) (*InstallConfig, error) {
+				return mock.config, mock.err
+			}, "0.6.0", "0.7.0")
+
+			got, err := getter(context.Background(), "install-config")
+			if mock.expectErr != (err != nil) {
+				t.Fatalf("expected error '%t': got '%v'", mock.expectErr, err)
+			}
+			if mock.expectErr {
+				return
+			}
+
+			var versions []string
+			for _, install := range got.Spec.Install {
+				versions = append(versions, install.Version)
+			}
+			if strings.Join(versions, ",") != strings.Join(mock.expected, ",") {
+				t.Fatalf("expected versions '%v': got '%v'", mock.expected, versions)
+			}
+			if mock.config.Spec.Install[0].Version == "0.7.0" {
+				t.Fatalf("expected original install config to be unchanged: got '%v'", mock.config.Spec.Install)
+			}
+		})
+	}
+}
+
+func TestUpgradeReportIsSuccess(t *testing.T) {
+	tests := map[string]struct {
+		report   UpgradeReport
+		expected bool
+	}{
+		"no errors":        {report: UpgradeReport{}, expected: true},
+		"errors":           {report: UpgradeReport{Errors: []string{"boom"}}},
+		"failed install":   {report: UpgradeReport{Install: InstallReport{Errors: []string{"boom"}}}},
+		"failed migration": {report: UpgradeReport{Migrations: []MigrationReportItem{{Name: "sc", Error: "boom"}}}},
+		"failed prune":     {report: UpgradeReport{Pruned: []PruneReportItem{{Name: "stale", Action: PruneActionFailed}}}},
+		"successful prune": {report: UpgradeReport{Pruned: []PruneReportItem{{Name: "stale", Action: PruneActionDeleted}}}, expected: true},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := mock.report.IsSuccess(); got != mock.expected {
+				t.Fatalf("expected success '%t': got '%t'", mock.expected, got)
+			}
+			if got := mock.report.ExitCode(); (got == 0) != mock.expected {
+				t.Fatalf("expected exit code to match success '%t': got '%d'", mock.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpgradeWithInvalidInstallConfig(t *testing.T) {
+	tests := map[string]struct {
+		getter      ConfigGetterFunc
+		from        string
+		expectError string
+	}{
+		"nil config getter": {
+			from:        "0.6.0",
+			expectError: "nil config getter",
+		},
+		"config getter fails": {
+			getter:      func(name string) (*InstallConfig, error) { return nil, fmt.Errorf("boom") },
+			from:        "0.6.0",
+			expectError: "boom",
+		},
+		"old version is not specified": {
+			getter: func(name string) (*InstallConfig, error) {
+				return &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.7.0"}}}}, nil
+			},
+			from:        "0.6.0",
+			expectError: "version '0.6.0' is not specified",
+		},
+	}
+
+	for name, mock := range tests {
+		t.Run(name, func(t *testing.T) {
+			i := &simpleInstaller{configGetter: IgnoreContextConfigGetter(mock.getter)}
+			report := i.Upgrade(context.Background(), mock.from, "0.7.0")
+			if report.IsSuccess() {
+				t.Fatalf("expected upgrade to fail: got success")
+			}
+			if len(report.Errors) != 1 || !strings.Contains(report.Errors[0], mock.expectError) {
+				t.Fatalf("expected error '%s': got '%v'", mock.expectError, report.Errors)
+			}
+			if report.Plan.From != mock.from || report.Plan.To != "0.7.0" {
+				t.Fatalf("expected plan from '%s' to '0.7.0': got '%s' to '%s'", mock.from, report.Plan.From, report.Plan.To)
+			}
+		})
+	}
+}
+
+func TestUpgradeWhenReused(t *testing.T) {
+	config := &InstallConfig{Spec: InstallConfigSpec{Install: []Install{{Version: "0.6.0"}}}}
+	i := NewSimpleInstaller(func(name string) (*InstallConfig, error) { return config, nil })
+	i.artifactLister = func(version string) (ArtifactList, error) {
+		return RegisteredArtifactsFor070(), nil
+	}
+	i.prune = true
+	i.waitForReadiness = false
+	i.addError(fmt.Errorf("stale error"))
+
+	// resources fail to apply in the absence of a kubernetes cluster
+	report := i.Upgrade(context.Background(), "0.6.0", "0.7.0")
+	if len(report.Plan.Items) == 0 {
+		t.Fatalf("expected upgrade to be planned: got errors '%v'", report.Install.Errors)
+	}
+	for _, err := range append(report.Errors, report.Install.Errors...) {
+		if strings.Contains(err, "stale error") {
+			t.Fatalf("expected errors of a previous run not to be reported: got '%s'", err)
+		}
+	}
+	if !i.prune {
+		t.Fatalf("expected prune to be restored after upgrade: got disabled")
+	}
+	got, err := i.configGetter(context.Background(), "install-config")
+	if err != nil {
+		t.Fatalf("expected no error: got '%v'", err)
+	}
+	if got.Spec.Install[0].Version != "0.6.0" {
+		t.Fatalf("expected config getter to be restored after upgrade: got version '%s'", got.Spec.Install[0].Version)
+	}
+}